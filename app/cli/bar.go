@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alesr/resterrdemo/service/bar"
+)
+
+type barService interface {
+	Fetch(ctx context.Context, id int64) (bar.Bar, error)
+	List(ctx context.Context) ([]bar.Bar, error)
+}
+
+// barCommands returns the commands of the bar resource, run with the bar service.
+func barCommands(barSvc barService, errHandler errorHandler) []command {
+	return []command{
+		{
+			resource:   "bar",
+			name:       "get",
+			operands:   []string{"id"},
+			summary:    "print the bar with the given id",
+			errHandler: errHandler,
+			run: func(ctx context.Context, operands []string) (any, error) {
+				id, err := parseID(operands[0])
+				if err != nil {
+					return nil, err
+				}
+
+				res, err := barSvc.Fetch(ctx, id)
+				if err != nil {
+					return nil, fmt.Errorf("could not get bar from service: %w", err)
+				}
+				return res, nil
+			},
+		},
+		{
+			resource:   "bar",
+			name:       "list",
+			summary:    "print every bar",
+			errHandler: errHandler,
+			run: func(ctx context.Context, _ []string) (any, error) {
+				res, err := barSvc.List(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("could not list bar from service: %w", err)
+				}
+				return res, nil
+			},
+		},
+	}
+}
@@ -0,0 +1,176 @@
+// cli package is the application's command line interface.
+// It runs a single command against the same services as the APIs, writing its result as JSON
+// to the standard output, and translating its errors into exit codes and messages written
+// to the standard error, with error maps of its own.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// name is the name of the program, prefixing the messages written to the standard error.
+const name = "resterrdemo"
+
+// tracerName is the name of the tracer of the application.
+const tracerName = "github.com/alesr/resterrdemo/app/cli"
+
+// errInvalidID is returned when an ID operand is not a valid entity ID.
+var errInvalidID = errors.New("invalid id")
+
+type errorHandler interface {
+	Handle(ctx context.Context, err error) Exit
+}
+
+// Option applies custom behavior to the application.
+type Option func(app *App)
+
+// WithTracerProvider is an option to trace the commands with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(app *App) {
+		app.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// command is a command of a resource, such as foo get.
+type command struct {
+	resource string
+	name     string
+	operands []string
+	summary  string
+
+	// run runs the command with its operands, returning the result to write to the standard output.
+	run        func(ctx context.Context, operands []string) (any, error)
+	errHandler errorHandler
+}
+
+// App implements the command line transport layer.
+type App struct {
+	logger   *slog.Logger
+	stdout   io.Writer
+	stderr   io.Writer
+	commands []command
+	tracer   trace.Tracer
+}
+
+// NewApp instantiates a new App struct, running the foo and bar commands with the services,
+// and translating their errors with the error handlers.
+func NewApp(logger *slog.Logger, stdout, stderr io.Writer, fooSvc fooService, fooErrHdler errorHandler, barSvc barService, barErrHdler errorHandler, opts ...Option) *App {
+	app := App{
+		logger: logger.WithGroup("cli"),
+		stdout: stdout,
+		stderr: stderr,
+		tracer: tracing.Tracer(nil, tracerName),
+	}
+
+	for _, o := range opts {
+		o(&app)
+	}
+
+	app.commands = append(app.commands, fooCommands(fooSvc, fooErrHdler)...)
+	app.commands = append(app.commands, barCommands(barSvc, barErrHdler)...)
+	return &app
+}
+
+// Run runs the command given by the arguments, such as foo get 1, and returns the code to exit with.
+// Invalid command lines are reported with the usage.
+func (app *App) Run(ctx context.Context, args []string) ExitCode {
+	cmd, ok := app.find(args)
+	if !ok {
+		app.usage(fmt.Sprintf("unknown command '%s'", strings.Join(args, " ")))
+		return ExitUsage
+	}
+
+	operands := args[2:]
+	if len(operands) != len(cmd.operands) {
+		app.usage(fmt.Sprintf("%s %s expects %d argument(s), got %d", cmd.resource, cmd.name, len(cmd.operands), len(operands)))
+		return ExitUsage
+	}
+
+	ctx, span := app.tracer.Start(ctx, cmd.resource+" "+cmd.name, trace.WithAttributes(
+		attribute.String("cli.command", cmd.resource+" "+cmd.name),
+	))
+	defer span.End()
+
+	res, err := cmd.run(ctx, operands)
+	if err == nil {
+		err = app.writeJSON(res)
+	}
+
+	if err != nil {
+		exit := cmd.errHandler.Handle(ctx, err)
+		fmt.Fprintf(app.stderr, "%s: %s\n", name, exit.Message)
+		span.SetAttributes(attribute.Int("process.exit.code", int(exit.Code)))
+		return exit.Code
+	}
+
+	span.SetAttributes(attribute.Int("process.exit.code", int(ExitOK)))
+	return ExitOK
+}
+
+// find returns the command named by the first two arguments.
+func (app *App) find(args []string) (command, bool) {
+	if len(args) < 2 {
+		return command{}, false
+	}
+
+	for _, c := range app.commands {
+		if c.resource == args[0] && c.name == args[1] {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// usage writes the reason the command line is invalid to the standard error, followed by the commands
+// and their exit codes.
+func (app *App) usage(reason string) {
+	fmt.Fprintf(app.stderr, "%s: %s\n\n", name, reason)
+	fmt.Fprintf(app.stderr, "Usage: %s [flags] <resource> <command> [arguments]\n\nCommands:\n", name)
+
+	tw := tabwriter.NewWriter(app.stderr, 0, 4, 2, ' ', 0)
+	for _, c := range app.commands {
+		line := c.resource + " " + c.name
+		for _, o := range c.operands {
+			line += " <" + o + ">"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", line, c.summary)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(app.stderr)
+	UsageExitCodes(app.stderr)
+
+	fmt.Fprintf(app.stderr, "\nRun '%s -h' to list the flags.\n", name)
+}
+
+// writeJSON writes the result of a command to the standard output.
+func (app *App) writeJSON(v any) error {
+	enc := json.NewEncoder(app.stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("could not write result: %w", err)
+	}
+	return nil
+}
+
+// parseID parses an ID operand.
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse id '%s': %w", s, errInvalidID)
+	}
+	return id, nil
+}
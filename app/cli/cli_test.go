@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+type fooServiceMock struct {
+	fetchFunc func(ctx context.Context, id int64) (foo.Foo, error)
+	listFunc  func(ctx context.Context) ([]foo.Foo, error)
+}
+
+func (m *fooServiceMock) Fetch(ctx context.Context, id int64) (foo.Foo, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *fooServiceMock) List(ctx context.Context) ([]foo.Foo, error) {
+	return m.listFunc(ctx)
+}
+
+type barServiceMock struct {
+	fetchFunc func(ctx context.Context, id int64) (bar.Bar, error)
+	listFunc  func(ctx context.Context) ([]bar.Bar, error)
+}
+
+func (m *barServiceMock) Fetch(ctx context.Context, id int64) (bar.Bar, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *barServiceMock) List(ctx context.Context) ([]bar.Bar, error) {
+	return m.listFunc(ctx)
+}
+
+func newTestApp(t *testing.T, stdout, stderr *bytes.Buffer, opts ...Option) *App {
+	t.Helper()
+
+	fooErrHandler, err := NewErrorHandler(noopLogger, FooErrMap)
+	require.NoError(t, err)
+
+	barErrHandler, err := NewErrorHandler(noopLogger, BarErrMap)
+	require.NoError(t, err)
+
+	fooSvc := fooServiceMock{
+		fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+			switch id {
+			case 1:
+				return foo.Foo{ID: 1, Name: "foo"}, nil
+			case 2:
+				return foo.Foo{}, fmt.Errorf("could not fetch foo: %w", foo.ErrFooNotFound)
+			case 3:
+				return foo.Foo{}, fmt.Errorf("could not fetch foo: %w", foo.ErrGetFaleid)
+			}
+			return foo.Foo{}, assert.AnError
+		},
+		listFunc: func(ctx context.Context) ([]foo.Foo, error) {
+			return []foo.Foo{{ID: 1, Name: "foo"}}, nil
+		},
+	}
+
+	barSvc := barServiceMock{
+		fetchFunc: func(ctx context.Context, id int64) (bar.Bar, error) {
+			return bar.Bar{}, fmt.Errorf("could not fetch bar: %w", bar.ErrNoSuchBar)
+		},
+		listFunc: func(ctx context.Context) ([]bar.Bar, error) {
+			return nil, fmt.Errorf("could not list bar: %w", context.DeadlineExceeded)
+		},
+	}
+	return NewApp(noopLogger, stdout, stderr, &fooSvc, fooErrHandler, &barSvc, barErrHandler, opts...)
+}
+
+func TestApp_Run(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedCode   ExitCode
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			name:           "foo get",
+			args:           []string{"foo", "get", "1"},
+			expectedCode:   ExitOK,
+			expectedStdout: "{\n  \"id\": 1,\n  \"name\": \"foo\"\n}\n",
+		},
+		{
+			name:           "foo list",
+			args:           []string{"foo", "list"},
+			expectedCode:   ExitOK,
+			expectedStdout: "[\n  {\n    \"id\": 1,\n    \"name\": \"foo\"\n  }\n]\n",
+		},
+		{
+			name:           "foo not found",
+			args:           []string{"foo", "get", "2"},
+			expectedCode:   ExitNotFound,
+			expectedStderr: "resterrdemo: foo not found\n",
+		},
+		{
+			name:           "foo unavailable",
+			args:           []string{"foo", "get", "3"},
+			expectedCode:   ExitUnavailable,
+			expectedStderr: "resterrdemo: could not perform the get foo operation\n",
+		},
+		{
+			name:           "foo unmapped error",
+			args:           []string{"foo", "get", "4"},
+			expectedCode:   ExitInternal,
+			expectedStderr: "resterrdemo: something went wrong\n",
+		},
+		{
+			name:           "invalid id",
+			args:           []string{"foo", "get", "one"},
+			expectedCode:   ExitUsage,
+			expectedStderr: "resterrdemo: the foo id must be an integer\n",
+		},
+		{
+			name:           "bar does not exist",
+			args:           []string{"bar", "get", "1"},
+			expectedCode:   ExitNotFound,
+			expectedStderr: "resterrdemo: bar does not exist\n",
+		},
+		{
+			name:           "bar list timed out",
+			args:           []string{"bar", "list"},
+			expectedCode:   ExitTimeout,
+			expectedStderr: "resterrdemo: the command timed out\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			app := newTestApp(t, &stdout, &stderr)
+
+			assert.Equal(t, tc.expectedCode, app.Run(context.TODO(), tc.args))
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+			assert.Equal(t, tc.expectedStderr, stderr.String())
+		})
+	}
+}
+
+func TestApp_Run_Usage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedReason string
+	}{
+		{
+			name:           "unknown resource",
+			args:           []string{"baz", "get", "1"},
+			expectedReason: "resterrdemo: unknown command 'baz get 1'\n",
+		},
+		{
+			name:           "missing command",
+			args:           []string{"foo"},
+			expectedReason: "resterrdemo: unknown command 'foo'\n",
+		},
+		{
+			name:           "missing argument",
+			args:           []string{"foo", "get"},
+			expectedReason: "resterrdemo: foo get expects 1 argument(s), got 0\n",
+		},
+		{
+			name:           "extra argument",
+			args:           []string{"bar", "list", "1"},
+			expectedReason: "resterrdemo: bar list expects 0 argument(s), got 1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			app := newTestApp(t, &stdout, &stderr)
+
+			assert.Equal(t, ExitUsage, app.Run(context.TODO(), tc.args))
+			assert.Empty(t, stdout.String())
+			assert.Contains(t, stderr.String(), tc.expectedReason)
+			assert.Contains(t, stderr.String(), "  foo get <id>  print the foo with the given id\n")
+			assert.Contains(t, stderr.String(), "  66   the entity does not exist\n")
+			assert.Contains(t, stderr.String(), "  130  the command was interrupted\n")
+		})
+	}
+}
+
+func TestApp_Run_Tracing(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	var stdout, stderr bytes.Buffer
+	app := newTestApp(t, &stdout, &stderr, WithTracerProvider(tp))
+
+	require.Equal(t, ExitNotFound, app.Run(context.TODO(), []string{"foo", "get", "2"}))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "foo get", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.String("cli.command", "foo get"))
+	assert.Contains(t, spans[0].Attributes, attribute.String("error.type", "foo.not_found"))
+	assert.Contains(t, spans[0].Attributes, attribute.Int("process.exit.code", int(ExitNotFound)))
+}
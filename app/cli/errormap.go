@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+// FooErrMap is the mapping between the errors of the foo commands and the exits they report.
+// Every public error of the foo service must be mapped here.
+// Context errors come first, since they describe the command rather than the entity.
+var FooErrMap = Map{
+	{Err: context.Canceled, Value: Exit{Code: ExitInterrupted, Message: "the command was interrupted"}},
+	{Err: context.DeadlineExceeded, Value: Exit{Code: ExitTimeout, Message: "the command timed out"}},
+	{Err: errInvalidID, Value: Exit{Code: ExitUsage, Message: "the foo id must be an integer"}},
+	{Err: foo.ErrFooNotFound, Value: Exit{Code: ExitNotFound, Message: "foo not found"}},
+	{Err: foo.ErrInvalidFoo, Value: Exit{Code: ExitInvalid, Message: "the foo name must not be empty"}},
+	{Err: foo.ErrGetFaleid, Value: Exit{Code: ExitUnavailable, Message: "could not perform the get foo operation"}},
+	{Err: foo.ErrListFailed, Value: Exit{Code: ExitUnavailable, Message: "could not perform the list foo operation"}},
+	{Err: foo.ErrCreateFailed, Value: Exit{Code: ExitUnavailable, Message: "could not perform the create foo operation"}},
+	{Err: foo.ErrUpdateFailed, Value: Exit{Code: ExitUnavailable, Message: "could not perform the update foo operation"}},
+	{Err: foo.ErrDeleteFailed, Value: Exit{Code: ExitUnavailable, Message: "could not perform the delete foo operation"}},
+}
+
+// BarErrMap is the mapping between the errors of the bar commands and the exits they report.
+// Every public error of the bar service must be mapped here.
+var BarErrMap = Map{
+	{Err: context.Canceled, Value: Exit{Code: ExitInterrupted, Message: "the command was interrupted"}},
+	{Err: context.DeadlineExceeded, Value: Exit{Code: ExitTimeout, Message: "the command timed out"}},
+	{Err: errInvalidID, Value: Exit{Code: ExitUsage, Message: "the bar id must be an integer"}},
+	{Err: bar.ErrNoSuchBar, Value: Exit{Code: ExitNotFound, Message: "bar does not exist"}},
+	{Err: bar.ErrInvalidBar, Value: Exit{Code: ExitInvalid, Message: "the bar name must not be empty"}},
+	{Err: bar.ErrBarNameTaken, Value: Exit{Code: ExitConflict, Message: "bar name is already taken"}},
+}
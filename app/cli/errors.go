@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/alesr/resterrdemo/app/errmap"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ExitCode is the status the process exits with. The codes are part of the command line contract,
+// so scripts can tell the failures apart; they start at 64 (as in sysexits.h) to never be mistaken
+// for the failures of the application startup.
+type ExitCode int
+
+// Exit codes of the commands, described by exitCodes.
+const (
+	ExitOK          ExitCode = 0
+	ExitUsage       ExitCode = 64
+	ExitInvalid     ExitCode = 65
+	ExitNotFound    ExitCode = 66
+	ExitConflict    ExitCode = 67
+	ExitUnavailable ExitCode = 69
+	ExitInternal    ExitCode = 70
+	ExitTimeout     ExitCode = 75
+	ExitInterrupted ExitCode = 130
+)
+
+// exitCodes describes the exit codes of the commands, in the order they are listed in the usage.
+var exitCodes = []struct {
+	code    ExitCode
+	meaning string
+}{
+	{code: ExitOK, meaning: "the command succeeded"},
+	{code: ExitUsage, meaning: "the command line is invalid"},
+	{code: ExitInvalid, meaning: "the input of the command is invalid"},
+	{code: ExitNotFound, meaning: "the entity does not exist"},
+	{code: ExitConflict, meaning: "the entity conflicts with an existing one"},
+	{code: ExitUnavailable, meaning: "a dependency is unavailable; the command may be retried"},
+	{code: ExitInternal, meaning: "the command failed unexpectedly; the details are logged"},
+	{code: ExitTimeout, meaning: "the command timed out; it may be retried"},
+	{code: ExitInterrupted, meaning: "the command was interrupted"},
+}
+
+// UsageExitCodes writes the exit codes of the commands and their meaning to w.
+func UsageExitCodes(w io.Writer) {
+	fmt.Fprintln(w, "Exit codes:")
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range exitCodes {
+		fmt.Fprintf(tw, "  %d\t%s\n", c.code, c.meaning)
+	}
+	_ = tw.Flush()
+}
+
+// Exit is the outcome a failed command reports: its exit code, and the message written to the standard error.
+// The message is part of the command line contract: it is safe to expose, and never carries the details of the error.
+type Exit struct {
+	Code    ExitCode
+	Message string
+}
+
+// Entry maps an error to the exit reported when the error is found in the chain of a handled error.
+type Entry = errmap.Entry[Exit]
+
+// Map is the ordered mapping between errors and exits, the command line analogue of the problem.Map
+// of the REST API. When a chain matches several entries, the first one wins.
+type Map = errmap.Map[Exit]
+
+// ErrorHandler translates the errors returned by the services into exits.
+type ErrorHandler = errmap.Handler[Exit]
+
+// internalExit is the exit reported for the errors that are not mapped, without details.
+var internalExit = Exit{Code: ExitInternal, Message: "something went wrong"}
+
+// translation translates the errors into exits. Mapped errors are expected outcomes reported
+// by the message, so they are only logged at debug level. Only the failures of the application
+// mark the span of the command as failed, the mistakes of the user being expected.
+var translation = errmap.Translation[Exit]{
+	Name: "cli",
+	Validate: func(e Exit) error {
+		if e.Code <= ExitOK {
+			return errors.New("code must be positive")
+		}
+
+		if e.Message == "" {
+			return errors.New("message is required")
+		}
+		return nil
+	},
+	Unmapped: func(error) Exit { return internalExit },
+	Attributes: func(e Exit) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.Int("process.exit.code", int(e.Code))}
+	},
+	Failed:      func(e Exit) bool { return isServerError(e.Code) },
+	MappedLevel: slog.LevelDebug,
+}
+
+// NewErrorHandler returns a command line error handler.
+// The exits in the error map must describe a failure, with a message.
+func NewErrorHandler(logger *slog.Logger, errorMap Map) (*ErrorHandler, error) {
+	return errmap.NewHandler(logger, errorMap, translation)
+}
+
+// isServerError reports whether the code describes a failure of the application rather than of the user.
+func isServerError(code ExitCode) bool {
+	switch code {
+	case ExitUnavailable, ExitInternal, ExitTimeout:
+		return true
+	}
+	return false
+}
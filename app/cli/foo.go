@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+type fooService interface {
+	Fetch(ctx context.Context, id int64) (foo.Foo, error)
+	List(ctx context.Context) ([]foo.Foo, error)
+}
+
+// fooCommands returns the commands of the foo resource, run with the foo service.
+func fooCommands(fooSvc fooService, errHandler errorHandler) []command {
+	return []command{
+		{
+			resource:   "foo",
+			name:       "get",
+			operands:   []string{"id"},
+			summary:    "print the foo with the given id",
+			errHandler: errHandler,
+			run: func(ctx context.Context, operands []string) (any, error) {
+				id, err := parseID(operands[0])
+				if err != nil {
+					return nil, err
+				}
+
+				res, err := fooSvc.Fetch(ctx, id)
+				if err != nil {
+					return nil, fmt.Errorf("could not get foo from service: %w", err)
+				}
+				return res, nil
+			},
+		},
+		{
+			resource:   "foo",
+			name:       "list",
+			summary:    "print every foo",
+			errHandler: errHandler,
+			run: func(ctx context.Context, _ []string) (any, error) {
+				res, err := fooSvc.List(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("could not list foo from service: %w", err)
+				}
+				return res, nil
+			},
+		},
+	}
+}
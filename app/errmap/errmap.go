@@ -0,0 +1,193 @@
+// errmap package translates the errors returned by the services with ordered error maps,
+// for every transport. Each transport supplies the value errors translate into, such as a problem,
+// a gRPC status or an exit, and how to handle it; looking up, logging and tracing the errors is shared.
+package errmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Entry maps an error to the value it translates into when the error is found in the chain of a handled error.
+type Entry[T any] struct {
+	Err   error
+	Value T
+}
+
+// Map is an ordered error map. When the chain of a handled error matches several entries,
+// the first one wins.
+type Map[T any] []Entry[T]
+
+// Find returns the first entry matching the chain of err.
+func (m Map[T]) Find(err error) (Entry[T], bool) {
+	for _, e := range m {
+		if errors.Is(err, e.Err) {
+			return e, true
+		}
+	}
+	return Entry[T]{}, false
+}
+
+// Lookup returns the value of the entry declared for the given error.
+func (m Map[T]) Lookup(err error) (T, bool) {
+	for _, e := range m {
+		if e.Err == err {
+			return e.Value, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// With returns a copy of the map where err translates into v, replacing the entry of err in place if any,
+// or appending one otherwise. It derives the error map of a new version of an API from the previous one.
+func (m Map[T]) With(err error, v T) Map[T] {
+	derived := make(Map[T], 0, len(m)+1)
+
+	var replaced bool
+	for _, e := range m {
+		if e.Err == err {
+			e.Value = v
+			replaced = true
+		}
+		derived = append(derived, e)
+	}
+
+	if !replaced {
+		derived = append(derived, Entry[T]{Err: err, Value: v})
+	}
+	return derived
+}
+
+// Translation is what a transport supplies to translate errors into values of type T.
+type Translation[T any] struct {
+	// Name names the transport in the log records of the handler.
+	Name string
+
+	// Validate checks the value of an error map entry, if set.
+	Validate func(v T) error
+
+	// Unmapped returns the value of the errors that are not mapped.
+	Unmapped func(err error) T
+
+	// Attributes describes the value of a handled error, in the log record and on the span.
+	Attributes func(v T) []attribute.KeyValue
+
+	// Failed reports whether the value describes a failure of the application rather than
+	// an expected outcome, marking the span as failed. If not set, no value does.
+	Failed func(v T) bool
+
+	// MappedLevel is the level the mapped errors are logged at.
+	// Unmapped errors are always logged as errors.
+	MappedLevel slog.Level
+}
+
+// Handler logs the errors returned by the services and translates them with an error map.
+type Handler[T any] struct {
+	logger   *slog.Logger
+	errorMap Map[T]
+	tr       Translation[T]
+}
+
+// NewHandler returns an error handler translating errors as described by the translation.
+// The values in the error map are validated.
+func NewHandler[T any](logger *slog.Logger, errorMap Map[T], tr Translation[T]) (*Handler[T], error) {
+	if tr.Unmapped == nil {
+		return nil, errors.New("translation has no value for the unmapped errors")
+	}
+
+	h := Handler[T]{
+		logger:   logger.WithGroup(tr.Name + "-error-handler"),
+		errorMap: make(Map[T], 0, len(errorMap)),
+		tr:       tr,
+	}
+
+	for _, e := range errorMap {
+		if e.Err == nil {
+			return nil, errors.New("error map entry has no error")
+		}
+
+		if tr.Validate != nil {
+			if err := tr.Validate(e.Value); err != nil {
+				return nil, fmt.Errorf("invalid value for error '%v': %w", e.Err, err)
+			}
+		}
+		h.errorMap = append(h.errorMap, e)
+	}
+	return &h, nil
+}
+
+// IsMapped reports whether the error is translated by the error map.
+func (h *Handler[T]) IsMapped(err error) bool {
+	_, ok := h.errorMap.Find(err)
+	return ok
+}
+
+// Handle logs the original error and returns the value it translates into,
+// from the first matching entry of the error map. Errors that are not mapped
+// translate into the value the transport gives them, without details.
+func (h *Handler[T]) Handle(ctx context.Context, err error) T {
+	e, ok := h.errorMap.Find(err)
+	if !ok {
+		v := h.tr.Unmapped(err)
+		h.log(ctx, slog.LevelError, "Handling unmapped error.", v, slog.String("source-error", err.Error()))
+		h.tr.Record(ctx, err, "unmapped", v)
+		return v
+	}
+
+	source := SentinelName(e.Err)
+	h.log(ctx, h.tr.MappedLevel, "Handling mapped error.", e.Value, slog.String("error", err.Error()), slog.String("mapped-by", source))
+	h.tr.Record(ctx, err, source, e.Value)
+	return e.Value
+}
+
+// log logs the handled error along with the attributes of its value.
+func (h *Handler[T]) log(ctx context.Context, level slog.Level, msg string, v T, attrs ...slog.Attr) {
+	for _, kv := range h.tr.attributes(v) {
+		attrs = append(attrs, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+	}
+	h.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// Record records the handled error on the span of the context, if any, along with the sentinel
+// that translated it and the attributes of its value. Only failures mark the span as failed.
+// Handle records the errors it handles; transports record the errors they translate themselves,
+// such as the panics of their handlers.
+func (tr Translation[T]) Record(ctx context.Context, err error, source string, v T) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := append([]attribute.KeyValue{attribute.String("error.type", source)}, tr.attributes(v)...)
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	span.SetAttributes(attribute.String("error.type", source))
+
+	if tr.Failed != nil && tr.Failed(v) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+func (tr Translation[T]) attributes(v T) []attribute.KeyValue {
+	if tr.Attributes == nil {
+		return nil
+	}
+	return tr.Attributes(v)
+}
+
+// SentinelName returns the name identifying a sentinel error:
+// the code of domain errors, or the error message otherwise.
+func SentinelName(err error) string {
+	if e, ok := err.(*domainerr.Error); ok {
+		return e.Code
+	}
+	return err.Error()
+}
@@ -0,0 +1,227 @@
+package errmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+// testTranslation translates errors into codes, the codes from 500 being failures.
+var testTranslation = Translation[int]{
+	Name: "test",
+	Validate: func(code int) error {
+		if code <= 0 {
+			return errors.New("code must be positive")
+		}
+		return nil
+	},
+	Unmapped:   func(error) int { return 500 },
+	Attributes: func(code int) []attribute.KeyValue { return []attribute.KeyValue{attribute.Int("test.code", code)} },
+	Failed:     func(code int) bool { return code >= 500 },
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	testCases := []struct {
+		name          string
+		errorMap      Map[int]
+		translation   Translation[int]
+		expectedError string
+	}{
+		{
+			name:        "valid map",
+			errorMap:    Map[int]{{Err: errFoo, Value: 404}},
+			translation: testTranslation,
+		},
+		{
+			name:          "entry without error",
+			errorMap:      Map[int]{{Value: 404}},
+			translation:   testTranslation,
+			expectedError: "error map entry has no error",
+		},
+		{
+			name:          "invalid value",
+			errorMap:      Map[int]{{Err: errFoo}},
+			translation:   testTranslation,
+			expectedError: "invalid value for error 'foo err': code must be positive",
+		},
+		{
+			name:          "no unmapped value",
+			errorMap:      Map[int]{{Err: errFoo, Value: 404}},
+			translation:   Translation[int]{Name: "test"},
+			expectedError: "translation has no value for the unmapped errors",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewHandler(noopLogger, tc.errorMap, tc.translation)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+	errTyped := domainerr.New(domainerr.KindNotFound, "foo.not_found", "foo not found")
+
+	handler, err := NewHandler(noopLogger, Map[int]{
+		{Err: context.Canceled, Value: 499},
+		{Err: errFoo, Value: 503},
+		{Err: errTyped, Value: 404},
+	}, testTranslation)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name               string
+		err                error
+		expectedValue      int
+		expectedMapped     bool
+		expectedErrorType  string
+		expectedStatusCode codes.Code
+	}{
+		{
+			name:               "mapped error",
+			err:                fmt.Errorf("wrapped: %w", errFoo),
+			expectedValue:      503,
+			expectedMapped:     true,
+			expectedErrorType:  "foo err",
+			expectedStatusCode: codes.Error,
+		},
+		{
+			name:               "domain error",
+			err:                errTyped.With("id", "1"),
+			expectedValue:      404,
+			expectedMapped:     true,
+			expectedErrorType:  "foo.not_found",
+			expectedStatusCode: codes.Unset,
+		},
+		{
+			name:               "first entry wins",
+			err:                fmt.Errorf("%w: %w", errTyped, context.Canceled),
+			expectedValue:      499,
+			expectedMapped:     true,
+			expectedErrorType:  "context canceled",
+			expectedStatusCode: codes.Unset,
+		},
+		{
+			name:               "unmapped error",
+			err:                fmt.Errorf("could not connect to 10.0.0.1: %w", assert.AnError),
+			expectedValue:      500,
+			expectedErrorType:  "unmapped",
+			expectedStatusCode: codes.Error,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test").Start(context.TODO(), "call")
+
+			got := handler.Handle(ctx, tc.err)
+			span.End()
+
+			assert.Equal(t, tc.expectedValue, got)
+			assert.Equal(t, tc.expectedMapped, handler.IsMapped(tc.err))
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.expectedStatusCode, spans[0].Status.Code)
+			assert.Contains(t, spans[0].Attributes, attribute.String("error.type", tc.expectedErrorType))
+
+			require.Len(t, spans[0].Events, 1)
+			assert.Contains(t, spans[0].Events[0].Attributes, attribute.Int("test.code", tc.expectedValue))
+		})
+	}
+}
+
+func TestMap_With(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+	errBar := errors.New("bar err")
+	errBaz := errors.New("baz err")
+
+	m := Map[int]{
+		{Err: errFoo, Value: 418},
+		{Err: errBar, Value: 404},
+	}
+
+	t.Run("replaces the entry in place", func(t *testing.T) {
+		t.Parallel()
+
+		got := m.With(errFoo, 503)
+
+		assert.Equal(t, Map[int]{
+			{Err: errFoo, Value: 503},
+			{Err: errBar, Value: 404},
+		}, got)
+	})
+
+	t.Run("appends a new entry", func(t *testing.T) {
+		t.Parallel()
+
+		got := m.With(errBaz, 409)
+
+		assert.Equal(t, Map[int]{
+			{Err: errFoo, Value: 418},
+			{Err: errBar, Value: 404},
+			{Err: errBaz, Value: 409},
+		}, got)
+	})
+
+	t.Run("leaves the map unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		_ = m.With(errFoo, 503)
+
+		v, ok := m.Lookup(errFoo)
+		require.True(t, ok)
+		assert.Equal(t, 418, v)
+	})
+}
+
+func TestMap_Lookup(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	m := Map[int]{{Err: errFoo, Value: 418}}
+
+	got, ok := m.Lookup(errFoo)
+	require.True(t, ok)
+	assert.Equal(t, 418, got)
+
+	// Lookup finds the entry declared for the error itself, not the ones matching its chain.
+	_, ok = m.Lookup(fmt.Errorf("wrapped: %w", errFoo))
+	assert.False(t, ok)
+}
@@ -0,0 +1,30 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alesr/resterrdemo/app/grpc/pb"
+	"github.com/alesr/resterrdemo/service/bar"
+)
+
+type barService interface {
+	Fetch(ctx context.Context, id int64) (bar.Bar, error)
+}
+
+// barServer implements the BarService RPCs on top of the bar service.
+type barServer struct {
+	pb.UnimplementedBarServiceServer
+
+	barSvc     barService
+	errHandler errorHandler
+}
+
+// GetBar implements the pb.BarServiceServer interface.
+func (s *barServer) GetBar(ctx context.Context, req *pb.GetBarRequest) (*pb.Bar, error) {
+	res, err := s.barSvc.Fetch(ctx, req.GetId())
+	if err != nil {
+		return nil, s.errHandler.Handle(ctx, fmt.Errorf("could not get bar from service: %w", err))
+	}
+	return &pb.Bar{Id: res.ID, Name: res.Name}, nil
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+	"google.golang.org/grpc/codes"
+)
+
+// FooErrMap is the mapping between the errors of the foo service and the statuses sent back
+// by the FooService RPCs. Every public error of the service must be mapped here.
+// Context errors come first, since they describe the call rather than the resource.
+var FooErrMap = Map{
+	{Err: context.Canceled, Value: Status{Code: codes.Canceled, Message: "the request was canceled"}},
+	{Err: context.DeadlineExceeded, Value: Status{Code: codes.DeadlineExceeded, Message: "the request timed out"}},
+	{Err: foo.ErrFooNotFound, Value: Status{Code: codes.NotFound, Message: "foo not found"}},
+	{Err: foo.ErrInvalidFoo, Value: Status{Code: codes.InvalidArgument, Message: "the foo name must not be empty"}},
+	{Err: foo.ErrGetFaleid, Value: Status{Code: codes.Unavailable, Message: "could not perform the get foo operation"}},
+	{Err: foo.ErrListFailed, Value: Status{Code: codes.Unavailable, Message: "could not perform the list foo operation"}},
+	{Err: foo.ErrCreateFailed, Value: Status{Code: codes.Unavailable, Message: "could not perform the create foo operation"}},
+	{Err: foo.ErrUpdateFailed, Value: Status{Code: codes.Unavailable, Message: "could not perform the update foo operation"}},
+	{Err: foo.ErrDeleteFailed, Value: Status{Code: codes.Unavailable, Message: "could not perform the delete foo operation"}},
+}
+
+// BarErrMap is the mapping between the errors of the bar service and the statuses sent back
+// by the BarService RPCs. Every public error of the service must be mapped here.
+var BarErrMap = Map{
+	{Err: context.Canceled, Value: Status{Code: codes.Canceled, Message: "the request was canceled"}},
+	{Err: context.DeadlineExceeded, Value: Status{Code: codes.DeadlineExceeded, Message: "the request timed out"}},
+	{Err: bar.ErrNoSuchBar, Value: Status{Code: codes.NotFound, Message: "bar does not exist"}},
+	{Err: bar.ErrInvalidBar, Value: Status{Code: codes.InvalidArgument, Message: "the bar name must not be empty"}},
+	{Err: bar.ErrBarNameTaken, Value: Status{Code: codes.AlreadyExists, Message: "bar name is already taken"}},
+}
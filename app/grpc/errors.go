@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/alesr/resterrdemo/app/errmap"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status is the gRPC status an error is sent to the client as.
+// The message is part of the API contract: it is safe to expose, and never carries the details of the error.
+type Status struct {
+	Code    codes.Code
+	Message string
+}
+
+// Entry maps an error to the status sent when the error is found in the chain of a handled error.
+type Entry = errmap.Entry[Status]
+
+// Map is the ordered mapping between errors and statuses, the gRPC analogue of the problem.Map
+// of the REST API. When a chain matches several entries, the first one wins.
+type Map = errmap.Map[Status]
+
+// internalStatus is the status sent for the errors that are not mapped, without details.
+var internalStatus = Status{Code: codes.Internal, Message: "something went wrong"}
+
+// translation translates the errors into statuses. Only server errors mark the span of the call
+// as failed, client errors being expected.
+var translation = errmap.Translation[Status]{
+	Name: "grpc",
+	Validate: func(s Status) error {
+		if s.Code == codes.OK {
+			return errors.New("code must not be OK")
+		}
+
+		if s.Message == "" {
+			return errors.New("message is required")
+		}
+		return nil
+	},
+	Unmapped: func(error) Status { return internalStatus },
+	Attributes: func(s Status) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", int(s.Code))}
+	},
+	Failed:      func(s Status) bool { return isServerError(s.Code) },
+	MappedLevel: slog.LevelError,
+}
+
+// ErrorHandler translates the errors returned by the services into gRPC status errors.
+type ErrorHandler struct {
+	*errmap.Handler[Status]
+}
+
+// NewErrorHandler returns a gRPC status error handler.
+// The statuses in the error map must describe an error, with a message.
+func NewErrorHandler(logger *slog.Logger, errorMap Map) (*ErrorHandler, error) {
+	h, err := errmap.NewHandler(logger, errorMap, translation)
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorHandler{Handler: h}, nil
+}
+
+// Handle logs the original error and returns the status error it translates into,
+// from the first matching entry of the error map. Errors that are not mapped
+// translate into an internal error without details.
+func (h *ErrorHandler) Handle(ctx context.Context, err error) error {
+	s := h.Handler.Handle(ctx, err)
+	return status.Error(s.Code, s.Message)
+}
+
+// isServerError reports whether the code describes a failure of the server rather than of the client.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alesr/resterrdemo/app/grpc/pb"
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+type fooService interface {
+	Fetch(ctx context.Context, id int64) (foo.Foo, error)
+}
+
+// fooServer implements the FooService RPCs on top of the foo service.
+type fooServer struct {
+	pb.UnimplementedFooServiceServer
+
+	fooSvc     fooService
+	errHandler errorHandler
+}
+
+// GetFoo implements the pb.FooServiceServer interface.
+func (s *fooServer) GetFoo(ctx context.Context, req *pb.GetFooRequest) (*pb.Foo, error) {
+	res, err := s.fooSvc.Fetch(ctx, req.GetId())
+	if err != nil {
+		return nil, s.errHandler.Handle(ctx, fmt.Errorf("could not get foo from service: %w", err))
+	}
+	return &pb.Foo{Id: res.ID, Name: res.Name}, nil
+}
@@ -0,0 +1,206 @@
+// grpc package is the application's gRPC API.
+// It serves the same services as the REST API, translating their errors
+// into gRPC statuses with error maps of its own.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"runtime/debug"
+
+	"github.com/alesr/resterrdemo/app/grpc/pb"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key carrying the request ID, in requests and response headers.
+const requestIDKey = "x-request-id"
+
+type errorHandler interface {
+	Handle(ctx context.Context, err error) error
+}
+
+// Option applies custom behavior to the server.
+type Option func(s *Server)
+
+// WithTracerProvider is an option to trace the calls with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Server) {
+		s.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// tracerName is the name of the tracer of the server.
+const tracerName = "github.com/alesr/resterrdemo/app/grpc"
+
+// errPanic is the error recorded when a handler panics.
+var errPanic = errors.New("handler panicked")
+
+// Server implements the gRPC transport layer.
+type Server struct {
+	logger *slog.Logger
+	addr   string
+	server *grpclib.Server
+
+	// tracer starts the span of each call, continuing the trace of the client
+	// propagated in the W3C trace context metadata, if any.
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewServer instantiates a new Server struct, serving the FooService and BarService RPCs
+// with the services, and translating their errors with the error handlers.
+func NewServer(logger *slog.Logger, cfg config.GRPC, fooSvc fooService, fooErrHdler errorHandler, barSvc barService, barErrHdler errorHandler, opts ...Option) *Server {
+	s := Server{
+		logger:     logger.WithGroup("grpc-server"),
+		addr:       cfg.Addr,
+		tracer:     tracing.Tracer(nil, tracerName),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, o := range opts {
+		o(&s)
+	}
+
+	s.server = grpclib.NewServer(grpclib.ChainUnaryInterceptor(s.requestID, s.trace, s.recoverer))
+	pb.RegisterFooServiceServer(s.server, &fooServer{fooSvc: fooSvc, errHandler: fooErrHdler})
+	pb.RegisterBarServiceServer(s.server, &barServer{barSvc: barSvc, errHandler: barErrHdler})
+	return &s
+}
+
+// requestID stores the request ID in the context of the call and sends it back in the response headers.
+// The ID provided by the client is used when it is valid. Otherwise, a new one is generated.
+func (s *Server) requestID(ctx context.Context, req any, _ *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (any, error) {
+	var id string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(values) > 0 {
+		id = values[0]
+	}
+	id = requestid.Resolve(id)
+
+	if err := grpclib.SetHeader(ctx, metadata.Pairs(requestIDKey, id)); err != nil {
+		s.logger.WarnContext(ctx, "Failed to set request ID header.", slog.String("error", err.Error()))
+	}
+	return handler(requestid.NewContext(ctx, id), req)
+}
+
+// trace traces each call in a server span, child of the span propagated by the client, if any.
+func (s *Server) trace(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = s.propagator.Extract(ctx, metadataCarrier(md))
+
+	ctx, span := s.tracer.Start(ctx, info.FullMethod[1:],
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+		),
+	)
+	defer span.End()
+
+	res, err := handler(ctx, req)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+	return res, err
+}
+
+// recoverer recovers from the panics of the handlers, logging them with their stack trace,
+// and sends an internal error to the client.
+func (s *Server) recoverer(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (res any, err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		s.logger.ErrorContext(
+			ctx,
+			"Recovered from handler panic.",
+			slog.String("method", info.FullMethod),
+			slog.Any("panic", v),
+			slog.String("stack", string(debug.Stack())),
+		)
+
+		translation.Record(ctx, fmt.Errorf("%w: %v", errPanic, v), "unmapped", internalStatus)
+		res, err = nil, status.Error(internalStatus.Code, internalStatus.Message)
+	}()
+
+	return handler(ctx, req)
+}
+
+// Run starts the server, serving on the address provided in the configuration.
+func (s *Server) Run() error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("could not listen: %w", err)
+	}
+	return s.Serve(lis)
+}
+
+// Serve serves the calls accepted on the listener, until the server is shut down.
+// It returns without error if the server is shut down, even before serving.
+func (s *Server) Serve(lis net.Listener) error {
+	s.logger.Info("Starting gRPC demo server.", slog.String("addr", lis.Addr().String()))
+
+	if err := s.server.Serve(lis); err != nil {
+		if errors.Is(err, grpclib.ErrServerStopped) {
+			s.logger.Warn("Server stopped before serving", slog.String("addr", lis.Addr().String()))
+			return nil
+		}
+		return fmt.Errorf("could not serve: %w", err)
+	}
+	return nil
+}
+
+// Shutdown gracefully shuts down the server: it stops accepting connections and drains the calls in flight
+// until the deadline of the context passed as an argument, after which the remaining connections are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.InfoContext(ctx, "Server shutting down")
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return fmt.Errorf("could not drain calls: %w", ctx.Err())
+	}
+}
+
+// metadataCarrier adapts the gRPC metadata to the propagation.TextMapCarrier interface.
+type metadataCarrier metadata.MD
+
+// Get implements the propagation.TextMapCarrier interface.
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set implements the propagation.TextMapCarrier interface.
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys implements the propagation.TextMapCarrier interface.
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,289 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alesr/resterrdemo/app/grpc/pb"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+type fooServiceMock struct {
+	fetchFunc func(ctx context.Context, id int64) (foo.Foo, error)
+}
+
+func (m *fooServiceMock) Fetch(ctx context.Context, id int64) (foo.Foo, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+type barServiceMock struct {
+	fetchFunc func(ctx context.Context, id int64) (bar.Bar, error)
+}
+
+func (m *barServiceMock) Fetch(ctx context.Context, id int64) (bar.Bar, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func TestServer_GetFoo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		fetchFunc       func(ctx context.Context, id int64) (foo.Foo, error)
+		expectedFoo     *pb.Foo
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		{
+			name: "found",
+			fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+				return foo.Foo{ID: id, Name: "foo"}, nil
+			},
+			expectedFoo:  &pb.Foo{Id: 42, Name: "foo"},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "not found",
+			fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+				return foo.Foo{}, foo.ErrFooNotFound
+			},
+			expectedCode:    codes.NotFound,
+			expectedMessage: "foo not found",
+		},
+		{
+			name: "get failed",
+			fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+				return foo.Foo{}, foo.ErrGetFaleid
+			},
+			expectedCode:    codes.Unavailable,
+			expectedMessage: "could not perform the get foo operation",
+		},
+		{
+			name: "hidden error",
+			fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+				return foo.Foo{}, foo.ErrFooStorageUnavailable
+			},
+			expectedCode:    codes.Internal,
+			expectedMessage: "something went wrong",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, newTestServer(t, &fooServiceMock{fetchFunc: tc.fetchFunc}, &barServiceMock{}))
+
+			got, err := pb.NewFooServiceClient(client).GetFoo(context.TODO(), &pb.GetFooRequest{Id: 42})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Equal(t, tc.expectedMessage, status.Convert(err).Message())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFoo.GetId(), got.GetId())
+			assert.Equal(t, tc.expectedFoo.GetName(), got.GetName())
+		})
+	}
+}
+
+func TestServer_GetBar(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		fetchErr        error
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		{name: "found", expectedCode: codes.OK},
+		{name: "no such bar", fetchErr: bar.ErrNoSuchBar, expectedCode: codes.NotFound, expectedMessage: "bar does not exist"},
+		{name: "unavailable is hidden", fetchErr: bar.ErrBarUnavailable, expectedCode: codes.Internal, expectedMessage: "something went wrong"},
+		{name: "deadline exceeded", fetchErr: context.DeadlineExceeded, expectedCode: codes.DeadlineExceeded, expectedMessage: "the request timed out"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			barSvc := barServiceMock{
+				fetchFunc: func(ctx context.Context, id int64) (bar.Bar, error) {
+					if tc.fetchErr != nil {
+						return bar.Bar{}, tc.fetchErr
+					}
+					return bar.Bar{ID: id, Name: "bar"}, nil
+				},
+			}
+
+			client := newTestClient(t, newTestServer(t, &fooServiceMock{}, &barSvc))
+
+			got, err := pb.NewBarServiceClient(client).GetBar(context.TODO(), &pb.GetBarRequest{Id: 7})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Equal(t, tc.expectedMessage, status.Convert(err).Message())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), got.GetId())
+			assert.Equal(t, "bar", got.GetName())
+		})
+	}
+}
+
+func TestServer_RequestID(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	fooSvc := fooServiceMock{
+		fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+			got = requestid.FromContext(ctx)
+			return foo.Foo{ID: id}, nil
+		},
+	}
+
+	client := pb.NewFooServiceClient(newTestClient(t, newTestServer(t, &fooSvc, &barServiceMock{})))
+
+	t.Run("provided by the client", func(t *testing.T) {
+		var header metadata.MD
+		ctx := metadata.AppendToOutgoingContext(context.TODO(), requestIDKey, "req-1")
+
+		_, err := client.GetFoo(ctx, &pb.GetFooRequest{Id: 1}, grpclib.Header(&header))
+		require.NoError(t, err)
+
+		assert.Equal(t, "req-1", got)
+		assert.Equal(t, []string{"req-1"}, header.Get(requestIDKey))
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		var header metadata.MD
+
+		_, err := client.GetFoo(context.TODO(), &pb.GetFooRequest{Id: 1}, grpclib.Header(&header))
+		require.NoError(t, err)
+
+		assert.Len(t, got, 32)
+		assert.Equal(t, []string{got}, header.Get(requestIDKey))
+	})
+}
+
+func TestServer_Recover(t *testing.T) {
+	t.Parallel()
+
+	fooSvc := fooServiceMock{
+		fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+			panic("foo exploded")
+		},
+	}
+
+	client := newTestClient(t, newTestServer(t, &fooSvc, &barServiceMock{}))
+
+	_, err := pb.NewFooServiceClient(client).GetFoo(context.TODO(), &pb.GetFooRequest{Id: 1})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "something went wrong", status.Convert(err).Message())
+}
+
+func TestServer_Tracing(t *testing.T) {
+	t.Parallel()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	fooSvc := fooServiceMock{
+		fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+			return foo.Foo{}, foo.ErrFooNotFound
+		},
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	server := newTestServer(t, &fooSvc, &barServiceMock{}, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+
+	ctx := metadata.AppendToOutgoingContext(context.TODO(), "traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	_, err := pb.NewFooServiceClient(newTestClient(t, server)).GetFoo(ctx, &pb.GetFooRequest{Id: 1})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "resterrdemo.v1.FooService/GetFoo", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, traceID, span.SpanContext.TraceID().String())
+	assert.True(t, span.Parent.IsRemote())
+	assert.Subset(t, span.Attributes, []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("error.type", "foo.not_found"),
+		attribute.Int("rpc.grpc.status_code", int(codes.NotFound)),
+	})
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(noopLogger, config.GRPC{}, &fooServiceMock{}, &ErrorHandler{}, &barServiceMock{}, &ErrorHandler{})
+
+	lis := bufconn.Listen(1 << 20)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(lis) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, server.Shutdown(ctx))
+	assert.NoError(t, <-served)
+}
+
+// newTestServer returns a server translating the errors with the error maps of the package.
+func newTestServer(t *testing.T, fooSvc fooService, barSvc barService, opts ...Option) *Server {
+	t.Helper()
+
+	fooErrHandler, err := NewErrorHandler(noopLogger, FooErrMap)
+	require.NoError(t, err)
+
+	barErrHandler, err := NewErrorHandler(noopLogger, BarErrMap)
+	require.NoError(t, err)
+
+	return NewServer(noopLogger, config.GRPC{}, fooSvc, fooErrHandler, barSvc, barErrHandler, opts...)
+}
+
+// newTestClient serves the server on an in-memory listener, and returns a connection to it.
+func newTestClient(t *testing.T, server *Server) *grpclib.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(func() { server.server.Stop() })
+
+	conn, err := grpclib.NewClient(
+		"passthrough:///bufnet",
+		grpclib.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpclib.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
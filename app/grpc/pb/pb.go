@@ -0,0 +1,5 @@
+// pb package holds the protocol buffers messages and services of the gRPC API,
+// generated from resterrdemo.proto with buf, protoc-gen-go and protoc-gen-go-grpc.
+package pb
+
+//go:generate buf generate
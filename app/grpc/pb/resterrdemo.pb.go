@@ -0,0 +1,361 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: resterrdemo.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetFooRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetFooRequest) Reset() {
+	*x = GetFooRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_resterrdemo_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFooRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFooRequest) ProtoMessage() {}
+
+func (x *GetFooRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_resterrdemo_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFooRequest.ProtoReflect.Descriptor instead.
+func (*GetFooRequest) Descriptor() ([]byte, []int) {
+	return file_resterrdemo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetFooRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Foo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *Foo) Reset() {
+	*x = Foo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_resterrdemo_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Foo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Foo) ProtoMessage() {}
+
+func (x *Foo) ProtoReflect() protoreflect.Message {
+	mi := &file_resterrdemo_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Foo.ProtoReflect.Descriptor instead.
+func (*Foo) Descriptor() ([]byte, []int) {
+	return file_resterrdemo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Foo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Foo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetBarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetBarRequest) Reset() {
+	*x = GetBarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_resterrdemo_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBarRequest) ProtoMessage() {}
+
+func (x *GetBarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_resterrdemo_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBarRequest.ProtoReflect.Descriptor instead.
+func (*GetBarRequest) Descriptor() ([]byte, []int) {
+	return file_resterrdemo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetBarRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Bar struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *Bar) Reset() {
+	*x = Bar{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_resterrdemo_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Bar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bar) ProtoMessage() {}
+
+func (x *Bar) ProtoReflect() protoreflect.Message {
+	mi := &file_resterrdemo_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bar.ProtoReflect.Descriptor instead.
+func (*Bar) Descriptor() ([]byte, []int) {
+	return file_resterrdemo_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Bar) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Bar) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+var File_resterrdemo_proto protoreflect.FileDescriptor
+
+var file_resterrdemo_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64, 0x65, 0x6d, 0x6f,
+	0x2e, 0x76, 0x31, 0x22, 0x1f, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x46, 0x6f, 0x6f, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x02, 0x69, 0x64, 0x22, 0x29, 0x0a, 0x03, 0x46, 0x6f, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22,
+	0x1f, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x42, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64,
+	0x22, 0x29, 0x0a, 0x03, 0x42, 0x61, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x32, 0x4a, 0x0a, 0x0a, 0x46,
+	0x6f, 0x6f, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3c, 0x0a, 0x06, 0x47, 0x65, 0x74,
+	0x46, 0x6f, 0x6f, 0x12, 0x1d, 0x2e, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64, 0x65, 0x6d,
+	0x6f, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x6f, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x13, 0x2e, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64, 0x65, 0x6d, 0x6f,
+	0x2e, 0x76, 0x31, 0x2e, 0x46, 0x6f, 0x6f, 0x32, 0x4a, 0x0a, 0x0a, 0x42, 0x61, 0x72, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3c, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x42, 0x61, 0x72, 0x12,
+	0x1d, 0x2e, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x65, 0x74, 0x42, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13,
+	0x2e, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x61, 0x72, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x61, 0x6c, 0x65, 0x73, 0x72, 0x2f, 0x72, 0x65, 0x73, 0x74, 0x65, 0x72, 0x72, 0x64,
+	0x65, 0x6d, 0x6f, 0x2f, 0x61, 0x70, 0x70, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_resterrdemo_proto_rawDescOnce sync.Once
+	file_resterrdemo_proto_rawDescData = file_resterrdemo_proto_rawDesc
+)
+
+func file_resterrdemo_proto_rawDescGZIP() []byte {
+	file_resterrdemo_proto_rawDescOnce.Do(func() {
+		file_resterrdemo_proto_rawDescData = protoimpl.X.CompressGZIP(file_resterrdemo_proto_rawDescData)
+	})
+	return file_resterrdemo_proto_rawDescData
+}
+
+var file_resterrdemo_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_resterrdemo_proto_goTypes = []any{
+	(*GetFooRequest)(nil), // 0: resterrdemo.v1.GetFooRequest
+	(*Foo)(nil),           // 1: resterrdemo.v1.Foo
+	(*GetBarRequest)(nil), // 2: resterrdemo.v1.GetBarRequest
+	(*Bar)(nil),           // 3: resterrdemo.v1.Bar
+}
+var file_resterrdemo_proto_depIdxs = []int32{
+	0, // 0: resterrdemo.v1.FooService.GetFoo:input_type -> resterrdemo.v1.GetFooRequest
+	2, // 1: resterrdemo.v1.BarService.GetBar:input_type -> resterrdemo.v1.GetBarRequest
+	1, // 2: resterrdemo.v1.FooService.GetFoo:output_type -> resterrdemo.v1.Foo
+	3, // 3: resterrdemo.v1.BarService.GetBar:output_type -> resterrdemo.v1.Bar
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_resterrdemo_proto_init() }
+func file_resterrdemo_proto_init() {
+	if File_resterrdemo_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_resterrdemo_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*GetFooRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_resterrdemo_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Foo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_resterrdemo_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*GetBarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_resterrdemo_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Bar); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_resterrdemo_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_resterrdemo_proto_goTypes,
+		DependencyIndexes: file_resterrdemo_proto_depIdxs,
+		MessageInfos:      file_resterrdemo_proto_msgTypes,
+	}.Build()
+	File_resterrdemo_proto = out.File
+	file_resterrdemo_proto_rawDesc = nil
+	file_resterrdemo_proto_goTypes = nil
+	file_resterrdemo_proto_depIdxs = nil
+}
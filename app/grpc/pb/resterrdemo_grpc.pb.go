@@ -0,0 +1,235 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: resterrdemo.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FooService_GetFoo_FullMethodName = "/resterrdemo.v1.FooService/GetFoo"
+)
+
+// FooServiceClient is the client API for FooService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FooService serves the foo entities.
+type FooServiceClient interface {
+	// GetFoo fetches the foo entity with the given ID.
+	GetFoo(ctx context.Context, in *GetFooRequest, opts ...grpc.CallOption) (*Foo, error)
+}
+
+type fooServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFooServiceClient(cc grpc.ClientConnInterface) FooServiceClient {
+	return &fooServiceClient{cc}
+}
+
+func (c *fooServiceClient) GetFoo(ctx context.Context, in *GetFooRequest, opts ...grpc.CallOption) (*Foo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Foo)
+	err := c.cc.Invoke(ctx, FooService_GetFoo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FooServiceServer is the server API for FooService service.
+// All implementations must embed UnimplementedFooServiceServer
+// for forward compatibility.
+//
+// FooService serves the foo entities.
+type FooServiceServer interface {
+	// GetFoo fetches the foo entity with the given ID.
+	GetFoo(context.Context, *GetFooRequest) (*Foo, error)
+	mustEmbedUnimplementedFooServiceServer()
+}
+
+// UnimplementedFooServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFooServiceServer struct{}
+
+func (UnimplementedFooServiceServer) GetFoo(context.Context, *GetFooRequest) (*Foo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFoo not implemented")
+}
+func (UnimplementedFooServiceServer) mustEmbedUnimplementedFooServiceServer() {}
+func (UnimplementedFooServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeFooServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FooServiceServer will
+// result in compilation errors.
+type UnsafeFooServiceServer interface {
+	mustEmbedUnimplementedFooServiceServer()
+}
+
+func RegisterFooServiceServer(s grpc.ServiceRegistrar, srv FooServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFooServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FooService_ServiceDesc, srv)
+}
+
+func _FooService_GetFoo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFooRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FooServiceServer).GetFoo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FooService_GetFoo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FooServiceServer).GetFoo(ctx, req.(*GetFooRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FooService_ServiceDesc is the grpc.ServiceDesc for FooService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FooService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "resterrdemo.v1.FooService",
+	HandlerType: (*FooServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFoo",
+			Handler:    _FooService_GetFoo_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "resterrdemo.proto",
+}
+
+const (
+	BarService_GetBar_FullMethodName = "/resterrdemo.v1.BarService/GetBar"
+)
+
+// BarServiceClient is the client API for BarService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BarService serves the bar entities.
+type BarServiceClient interface {
+	// GetBar fetches the bar entity with the given ID.
+	GetBar(ctx context.Context, in *GetBarRequest, opts ...grpc.CallOption) (*Bar, error)
+}
+
+type barServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBarServiceClient(cc grpc.ClientConnInterface) BarServiceClient {
+	return &barServiceClient{cc}
+}
+
+func (c *barServiceClient) GetBar(ctx context.Context, in *GetBarRequest, opts ...grpc.CallOption) (*Bar, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Bar)
+	err := c.cc.Invoke(ctx, BarService_GetBar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BarServiceServer is the server API for BarService service.
+// All implementations must embed UnimplementedBarServiceServer
+// for forward compatibility.
+//
+// BarService serves the bar entities.
+type BarServiceServer interface {
+	// GetBar fetches the bar entity with the given ID.
+	GetBar(context.Context, *GetBarRequest) (*Bar, error)
+	mustEmbedUnimplementedBarServiceServer()
+}
+
+// UnimplementedBarServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBarServiceServer struct{}
+
+func (UnimplementedBarServiceServer) GetBar(context.Context, *GetBarRequest) (*Bar, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBar not implemented")
+}
+func (UnimplementedBarServiceServer) mustEmbedUnimplementedBarServiceServer() {}
+func (UnimplementedBarServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeBarServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BarServiceServer will
+// result in compilation errors.
+type UnsafeBarServiceServer interface {
+	mustEmbedUnimplementedBarServiceServer()
+}
+
+func RegisterBarServiceServer(s grpc.ServiceRegistrar, srv BarServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBarServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BarService_ServiceDesc, srv)
+}
+
+func _BarService_GetBar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BarServiceServer).GetBar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BarService_GetBar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BarServiceServer).GetBar(ctx, req.(*GetBarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BarService_ServiceDesc is the grpc.ServiceDesc for BarService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BarService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "resterrdemo.v1.BarService",
+	HandlerType: (*BarServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBar",
+			Handler:    _BarService_GetBar_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "resterrdemo.proto",
+}
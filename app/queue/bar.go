@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alesr/resterrdemo/service/bar"
+)
+
+// Types of the bar messages.
+const (
+	TypeBarCreate = "bar.create"
+	TypeBarUpdate = "bar.update"
+	TypeBarDelete = "bar.delete"
+)
+
+type barService interface {
+	Create(ctx context.Context, newBar bar.Bar) (bar.Bar, error)
+	Update(ctx context.Context, updated bar.Bar) (bar.Bar, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// barCreateMessage is the body of the bar.create messages.
+type barCreateMessage struct {
+	Name string `json:"name"`
+}
+
+// barUpdateMessage is the body of the bar.update messages.
+type barUpdateMessage struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// barDeleteMessage is the body of the bar.delete messages.
+type barDeleteMessage struct {
+	ID int64 `json:"id"`
+}
+
+// barHandlers returns the handlers of the bar messages by type, processing them with the bar service.
+func barHandlers(barSvc barService, errHandler errorHandler) map[string]handler {
+	return map[string]handler{
+		TypeBarCreate: {
+			errHandler: errHandler,
+			handle: func(ctx context.Context, body []byte) error {
+				var msg barCreateMessage
+				if err := decodeBody(body, &msg); err != nil {
+					return err
+				}
+
+				if _, err := barSvc.Create(ctx, bar.Bar{Name: msg.Name}); err != nil {
+					return fmt.Errorf("could not create bar from service: %w", err)
+				}
+				return nil
+			},
+		},
+		TypeBarUpdate: {
+			errHandler: errHandler,
+			handle: func(ctx context.Context, body []byte) error {
+				var msg barUpdateMessage
+				if err := decodeBody(body, &msg); err != nil {
+					return err
+				}
+
+				if _, err := barSvc.Update(ctx, bar.Bar{ID: msg.ID, Name: msg.Name}); err != nil {
+					return fmt.Errorf("could not update bar from service: %w", err)
+				}
+				return nil
+			},
+		},
+		TypeBarDelete: {
+			errHandler: errHandler,
+			handle: func(ctx context.Context, body []byte) error {
+				var msg barDeleteMessage
+				if err := decodeBody(body, &msg); err != nil {
+					return err
+				}
+
+				if err := barSvc.Delete(ctx, msg.ID); err != nil {
+					return fmt.Errorf("could not delete bar from service: %w", err)
+				}
+				return nil
+			},
+		},
+	}
+}
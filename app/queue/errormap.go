@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+// FooErrMap is the decision table of the foo messages whose handling failed.
+// Every public error of the foo service must be mapped here. Since the queue has no client
+// the errors could be leaked to, hidden errors may be mapped as well.
+// Context errors come first: they tell that the consumer is stopping, so the message is delivered again.
+var FooErrMap = Map{
+	{Err: context.Canceled, Value: Retry},
+	{Err: context.DeadlineExceeded, Value: Retry},
+	{Err: errInvalidBody, Value: DeadLetter},
+	{Err: foo.ErrFooNotFound, Value: DeadLetter},
+	{Err: foo.ErrInvalidFoo, Value: DeadLetter},
+	{Err: foo.ErrGetFaleid, Value: Retry},
+	{Err: foo.ErrListFailed, Value: Retry},
+	{Err: foo.ErrCreateFailed, Value: Retry},
+	{Err: foo.ErrUpdateFailed, Value: Retry},
+	{Err: foo.ErrDeleteFailed, Value: Retry},
+}
+
+// BarErrMap is the decision table of the bar messages whose handling failed.
+// Every public error of the bar service must be mapped here. Since the queue has no client
+// the errors could be leaked to, hidden errors may be mapped as well.
+var BarErrMap = Map{
+	{Err: context.Canceled, Value: Retry},
+	{Err: context.DeadlineExceeded, Value: Retry},
+	{Err: errInvalidBody, Value: DeadLetter},
+	{Err: bar.ErrBarUnavailable, Value: Retry},
+	{Err: bar.ErrNoSuchBar, Value: DeadLetter},
+	{Err: bar.ErrBarNameTaken, Value: DeadLetter},
+	{Err: bar.ErrInvalidBar, Value: DeadLetter},
+}
@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/alesr/resterrdemo/app/errmap"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Action is what the consumer does with a message once it has been handled.
+type Action int
+
+const (
+	// Ack removes the message from the queue.
+	Ack Action = iota + 1
+
+	// Retry delivers the message again after a backoff, as long as it has attempts left.
+	Retry
+
+	// DeadLetter moves the message to the dead-letter queue, where it waits for an operator.
+	DeadLetter
+)
+
+// String implements the fmt.Stringer interface.
+func (a Action) String() string {
+	switch a {
+	case Ack:
+		return "ack"
+	case Retry:
+		return "retry"
+	case DeadLetter:
+		return "dead-letter"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Entry maps an error to the action taken when the error is found in the chain of a handled error.
+type Entry = errmap.Entry[Action]
+
+// Map is the ordered decision table between errors and actions, the queue analogue of the problem.Map
+// of the REST API. When a chain matches several entries, the first one wins.
+type Map = errmap.Map[Action]
+
+// ErrorHandler decides what to do with the messages whose handling failed.
+type ErrorHandler = errmap.Handler[Action]
+
+// translation translates the errors into actions. Errors that are not mapped are retried when they are
+// retryable domain errors or unexpected errors, which may be transient, and dead-lettered otherwise.
+// Only the messages given up on mark the span of the message as failed, retries being expected.
+var translation = errmap.Translation[Action]{
+	Name: "queue",
+	Validate: func(a Action) error {
+		if a < Ack || a > DeadLetter {
+			return fmt.Errorf("invalid action %s", a)
+		}
+		return nil
+	},
+	Unmapped: defaultAction,
+	Attributes: func(a Action) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.String("queue.action", a.String())}
+	},
+	Failed:      func(a Action) bool { return a == DeadLetter },
+	MappedLevel: slog.LevelWarn,
+}
+
+// NewErrorHandler returns a queue error handler.
+// The actions in the decision table must be valid.
+func NewErrorHandler(logger *slog.Logger, errorMap Map) (*ErrorHandler, error) {
+	return errmap.NewHandler(logger, errorMap, translation)
+}
+
+// defaultAction returns the action called for by an error that is not mapped.
+func defaultAction(err error) Action {
+	var domainErr *domainerr.Error
+	if errors.As(err, &domainErr) && !domainErr.Retryable {
+		return DeadLetter
+	}
+	return Retry
+}
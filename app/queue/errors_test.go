@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultAction(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected Action
+	}{
+		{
+			name:     "retryable domain error",
+			err:      fmt.Errorf("wrapped: %w", domainerr.NewRetryable(domainerr.KindUnavailable, "foo.down", "foo is down")),
+			expected: Retry,
+		},
+		{
+			name:     "domain error",
+			err:      fmt.Errorf("wrapped: %w", domainerr.New(domainerr.KindConflict, "foo.taken", "foo is taken")),
+			expected: DeadLetter,
+		},
+		{
+			name:     "unexpected error",
+			err:      fmt.Errorf("could not connect to 10.0.0.1: %w", assert.AnError),
+			expected: Retry,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, defaultAction(tc.err))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+// Types of the foo messages.
+const (
+	TypeFooCreate = "foo.create"
+	TypeFooUpdate = "foo.update"
+	TypeFooDelete = "foo.delete"
+)
+
+type fooService interface {
+	Create(ctx context.Context, newFoo foo.Foo) (foo.Foo, error)
+	Update(ctx context.Context, updated foo.Foo) (foo.Foo, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// fooCreateMessage is the body of the foo.create messages.
+type fooCreateMessage struct {
+	Name string `json:"name"`
+}
+
+// fooUpdateMessage is the body of the foo.update messages.
+type fooUpdateMessage struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// fooDeleteMessage is the body of the foo.delete messages.
+type fooDeleteMessage struct {
+	ID int64 `json:"id"`
+}
+
+// fooHandlers returns the handlers of the foo messages by type, processing them with the foo service.
+func fooHandlers(fooSvc fooService, errHandler errorHandler) map[string]handler {
+	return map[string]handler{
+		TypeFooCreate: {
+			errHandler: errHandler,
+			handle: func(ctx context.Context, body []byte) error {
+				var msg fooCreateMessage
+				if err := decodeBody(body, &msg); err != nil {
+					return err
+				}
+
+				if _, err := fooSvc.Create(ctx, foo.Foo{Name: msg.Name}); err != nil {
+					return fmt.Errorf("could not create foo from service: %w", err)
+				}
+				return nil
+			},
+		},
+		TypeFooUpdate: {
+			errHandler: errHandler,
+			handle: func(ctx context.Context, body []byte) error {
+				var msg fooUpdateMessage
+				if err := decodeBody(body, &msg); err != nil {
+					return err
+				}
+
+				if _, err := fooSvc.Update(ctx, foo.Foo{ID: msg.ID, Name: msg.Name}); err != nil {
+					return fmt.Errorf("could not update foo from service: %w", err)
+				}
+				return nil
+			},
+		},
+		TypeFooDelete: {
+			errHandler: errHandler,
+			handle: func(ctx context.Context, body []byte) error {
+				var msg fooDeleteMessage
+				if err := decodeBody(body, &msg); err != nil {
+					return err
+				}
+
+				if err := fooSvc.Delete(ctx, msg.ID); err != nil {
+					return fmt.Errorf("could not delete foo from service: %w", err)
+				}
+				return nil
+			},
+		},
+	}
+}
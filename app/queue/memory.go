@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBrokerClosed is returned by the operations of a closed broker.
+var ErrBrokerClosed = errors.New("broker closed")
+
+// DeadLetterMessage is a message moved to the dead-letter queue, with the error that made the consumer give up on it.
+type DeadLetterMessage struct {
+	Message Message
+	Reason  error
+}
+
+// MemoryBroker is a Broker keeping its queues in memory, for tests and local runs.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	pending     []Message
+	acked       []Message
+	deadLetters []DeadLetterMessage
+	timers      []*time.Timer
+	lastID      int
+	closed      bool
+
+	// ready is signaled when messages are pending, and done is closed with the broker.
+	ready chan struct{}
+	done  chan struct{}
+}
+
+// NewMemoryBroker returns an empty in-memory broker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{ready: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+// Publish adds a message to the queue, delivered for the first time.
+// A message without ID is given one.
+func (b *MemoryBroker) Publish(msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	if msg.ID == "" {
+		b.lastID++
+		msg.ID = strconv.Itoa(b.lastID)
+	}
+	msg.Attempt = 1
+
+	b.push(msg)
+	return nil
+}
+
+// Receive implements the Broker interface.
+func (b *MemoryBroker) Receive(ctx context.Context) (Message, error) {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return Message{}, ErrBrokerClosed
+		}
+
+		if len(b.pending) > 0 {
+			msg := b.pending[0]
+			b.pending = b.pending[1:]
+
+			// Wake up the next receiver, if other messages are pending.
+			if len(b.pending) > 0 {
+				b.signal()
+			}
+			b.mu.Unlock()
+			return msg, nil
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-b.done:
+			return Message{}, ErrBrokerClosed
+		case <-b.ready:
+		}
+	}
+}
+
+// Ack implements the Broker interface.
+func (b *MemoryBroker) Ack(_ context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.acked = append(b.acked, msg)
+	return nil
+}
+
+// Retry implements the Broker interface.
+func (b *MemoryBroker) Retry(_ context.Context, msg Message, delay time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	msg.Attempt++
+	b.timers = append(b.timers, time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if !b.closed {
+			b.push(msg)
+		}
+	}))
+	return nil
+}
+
+// DeadLetter implements the Broker interface.
+func (b *MemoryBroker) DeadLetter(_ context.Context, msg Message, reason error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.deadLetters = append(b.deadLetters, DeadLetterMessage{Message: msg, Reason: reason})
+	return nil
+}
+
+// Acked returns the messages acknowledged so far.
+func (b *MemoryBroker) Acked() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return slices.Clone(b.acked)
+}
+
+// DeadLetters returns the messages of the dead-letter queue.
+func (b *MemoryBroker) DeadLetters() []DeadLetterMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return slices.Clone(b.deadLetters)
+}
+
+// Close stops the pending retries and fails the operations that follow.
+func (b *MemoryBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for _, t := range b.timers {
+		t.Stop()
+	}
+	close(b.done)
+}
+
+// push adds the message to the pending ones. The lock must be held.
+func (b *MemoryBroker) push(msg Message) {
+	b.pending = append(b.pending, msg)
+	b.signal()
+}
+
+// signal wakes up a receiver, if one is waiting and none was signaled yet.
+func (b *MemoryBroker) signal() {
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	t.Parallel()
+
+	broker := NewMemoryBroker()
+	ctx := context.TODO()
+
+	require.NoError(t, broker.Publish(Message{Type: TypeFooCreate}))
+	require.NoError(t, broker.Publish(Message{ID: "custom", Type: TypeBarCreate}))
+
+	first, err := broker.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Message{ID: "1", Type: TypeFooCreate, Attempt: 1}, first)
+
+	second, err := broker.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Message{ID: "custom", Type: TypeBarCreate, Attempt: 1}, second)
+
+	require.NoError(t, broker.Retry(ctx, first, time.Millisecond))
+
+	retried, err := broker.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1", retried.ID)
+	assert.Equal(t, 2, retried.Attempt)
+
+	require.NoError(t, broker.Ack(ctx, retried))
+	require.NoError(t, broker.DeadLetter(ctx, second, assert.AnError))
+
+	assert.Equal(t, []Message{retried}, broker.Acked())
+	assert.Equal(t, []DeadLetterMessage{{Message: second, Reason: assert.AnError}}, broker.DeadLetters())
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+
+	_, err = broker.Receive(timeoutCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMemoryBroker_Close(t *testing.T) {
+	t.Parallel()
+
+	broker := NewMemoryBroker()
+
+	received := make(chan error, 1)
+	go func() {
+		_, err := broker.Receive(context.TODO())
+		received <- err
+	}()
+
+	broker.Close()
+	assert.ErrorIs(t, <-received, ErrBrokerClosed)
+	assert.ErrorIs(t, broker.Publish(Message{Type: TypeFooCreate}), ErrBrokerClosed)
+}
@@ -0,0 +1,284 @@
+// queue package is the application's message queue consumer.
+// It processes the foo and bar commands published on a queue with the same services as the APIs,
+// and decides, with decision tables of its own, whether each failed message is acknowledged,
+// retried with a backoff, or moved to the dead-letter queue.
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID is the message header carrying the request ID.
+const HeaderRequestID = "x-request-id"
+
+// tracerName is the name of the tracer of the consumer.
+const tracerName = "github.com/alesr/resterrdemo/app/queue"
+
+var (
+	// errInvalidBody is returned when the body of a message cannot be decoded.
+	errInvalidBody = errors.New("invalid message body")
+
+	// errUnknownType is returned when no handler processes the type of a message.
+	errUnknownType = errors.New("unknown message type")
+
+	// errPanic is returned when a handler panics.
+	errPanic = errors.New("handler panicked")
+)
+
+// Message is a command published on the queue.
+type Message struct {
+	// ID identifies the message in the queue.
+	ID string
+
+	// Type names the command, such as foo.create. It selects the handler of the message.
+	Type string
+
+	// Body is the JSON encoded command.
+	Body []byte
+
+	// Headers carry the request ID and the W3C trace context of the publisher, if any.
+	Headers map[string]string
+
+	// Attempt counts the deliveries of the message, starting at 1.
+	Attempt int
+}
+
+// Broker delivers the messages of the queue to the consumer, and takes them back once handled.
+type Broker interface {
+	// Receive blocks until a message is available or the context is done.
+	Receive(ctx context.Context) (Message, error)
+
+	// Ack removes the message from the queue.
+	Ack(ctx context.Context, msg Message) error
+
+	// Retry delivers the message again after the delay, with its attempt incremented.
+	Retry(ctx context.Context, msg Message, delay time.Duration) error
+
+	// DeadLetter moves the message to the dead-letter queue, with the error that made the consumer give up on it.
+	DeadLetter(ctx context.Context, msg Message, reason error) error
+}
+
+// Backoff computes the delay before delivering a failed message again.
+// The delay doubles with every attempt, from Initial up to Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Delay returns the delay before the delivery following the attempt.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 1; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	return min(d, b.Max)
+}
+
+// DefaultBackoff is the backoff of the consumers created without the WithBackoff option.
+var DefaultBackoff = Backoff{Initial: 100 * time.Millisecond, Max: 10 * time.Second}
+
+// DefaultMaxAttempts is the number of deliveries of a message before it is dead-lettered,
+// for the consumers created without the WithMaxAttempts option.
+const DefaultMaxAttempts = 5
+
+type errorHandler interface {
+	Handle(ctx context.Context, err error) Action
+}
+
+// handler processes the messages of a type, its errors being handled by the error handler of its resource.
+type handler struct {
+	handle     func(ctx context.Context, body []byte) error
+	errHandler errorHandler
+}
+
+// Option applies custom behavior to the consumer.
+type Option func(c *Consumer)
+
+// WithBackoff is an option to space the deliveries of a failed message with the backoff.
+func WithBackoff(b Backoff) Option {
+	return func(c *Consumer) {
+		c.backoff = b
+	}
+}
+
+// WithMaxAttempts is an option to dead-letter the messages still failing after n deliveries.
+func WithMaxAttempts(n int) Option {
+	return func(c *Consumer) {
+		c.maxAttempts = n
+	}
+}
+
+// WithTracerProvider is an option to trace the messages with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Consumer) {
+		c.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// Consumer implements the message queue transport layer.
+type Consumer struct {
+	logger      *slog.Logger
+	broker      Broker
+	handlers    map[string]handler
+	backoff     Backoff
+	maxAttempts int
+
+	// tracer starts the span of each message, continuing the trace of the publisher
+	// propagated in the W3C trace context headers, if any.
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewConsumer instantiates a new Consumer struct, processing the foo and bar messages
+// delivered by the broker with the services, and handling their errors with the error handlers.
+func NewConsumer(logger *slog.Logger, broker Broker, fooSvc fooService, fooErrHdler errorHandler, barSvc barService, barErrHdler errorHandler, opts ...Option) *Consumer {
+	c := Consumer{
+		logger:      logger.WithGroup("queue-consumer"),
+		broker:      broker,
+		handlers:    make(map[string]handler),
+		backoff:     DefaultBackoff,
+		maxAttempts: DefaultMaxAttempts,
+		tracer:      tracing.Tracer(nil, tracerName),
+		propagator:  propagation.TraceContext{},
+	}
+
+	for _, o := range opts {
+		o(&c)
+	}
+
+	for typ, h := range fooHandlers(fooSvc, fooErrHdler) {
+		c.handlers[typ] = h
+	}
+
+	for typ, h := range barHandlers(barSvc, barErrHdler) {
+		c.handlers[typ] = h
+	}
+	return &c
+}
+
+// Run processes the messages delivered by the broker, one at a time, until the context is done.
+// A message being processed when the context is done is retried, so it is not lost.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		msg, err := c.broker.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("could not receive message: %w", err)
+		}
+		c.process(ctx, msg)
+	}
+}
+
+// process handles a message and settles it with the broker, according to the decision
+// table of its resource when its handling fails.
+func (c *Consumer) process(ctx context.Context, msg Message) {
+	ctx = requestid.NewContext(ctx, requestid.Resolve(msg.Headers[HeaderRequestID]))
+	ctx = c.propagator.Extract(ctx, propagation.MapCarrier(msg.Headers))
+
+	ctx, span := c.tracer.Start(ctx, msg.Type+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.operation.type", "process"),
+			attribute.String("messaging.message.id", msg.ID),
+			attribute.Int("messaging.message.delivery_count", msg.Attempt),
+		),
+	)
+	defer span.End()
+
+	action, err := c.handle(ctx, msg)
+
+	// A message interrupted by the consumer stopping is retried even on its last delivery,
+	// since its failure says nothing about the message.
+	if action == Retry && msg.Attempt >= c.maxAttempts && ctx.Err() == nil {
+		c.logger.WarnContext(ctx, "Retries exhausted.", slog.String("message-id", msg.ID), slog.Int("attempts", msg.Attempt))
+		span.SetStatus(codes.Error, err.Error())
+		action = DeadLetter
+	}
+	span.SetAttributes(attribute.String("queue.action", action.String()))
+
+	if err := c.settle(ctx, msg, action, err); err != nil {
+		c.logger.ErrorContext(ctx, "Failed to settle message.", slog.String("message-id", msg.ID), slog.String("action", action.String()), slog.String("error", err.Error()))
+		_ = tracing.RecordError(span, err)
+	}
+}
+
+// handle runs the handler of the message and returns the action it calls for, along with
+// the error of the handler, if any. Messages of unknown types and messages whose handler panics
+// are dead-lettered, since delivering them again would fail the same way.
+func (c *Consumer) handle(ctx context.Context, msg Message) (action Action, err error) {
+	h, ok := c.handlers[msg.Type]
+	if !ok {
+		err := fmt.Errorf("could not handle message of type '%s': %w", msg.Type, errUnknownType)
+		c.logger.ErrorContext(ctx, "Handling unknown message type.", slog.String("message-id", msg.ID), slog.String("type", msg.Type))
+		translation.Record(ctx, err, errUnknownType.Error(), DeadLetter)
+		return DeadLetter, err
+	}
+
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		c.logger.ErrorContext(
+			ctx,
+			"Recovered from handler panic.",
+			slog.String("type", msg.Type),
+			slog.Any("panic", v),
+			slog.String("stack", string(debug.Stack())),
+		)
+
+		err = fmt.Errorf("%w: %v", errPanic, v)
+		translation.Record(ctx, err, "unmapped", DeadLetter)
+		action = DeadLetter
+	}()
+
+	if err := h.handle(ctx, msg.Body); err != nil {
+		return h.errHandler.Handle(ctx, err), err
+	}
+	return Ack, nil
+}
+
+// settle tells the broker what to do with the handled message.
+// The settlement outlives the context of the consumer, so that the messages being processed
+// when the consumer stops are not left unsettled.
+func (c *Consumer) settle(ctx context.Context, msg Message, action Action, reason error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	switch action {
+	case Ack:
+		return c.broker.Ack(ctx, msg)
+	case Retry:
+		return c.broker.Retry(ctx, msg, c.backoff.Delay(msg.Attempt))
+	case DeadLetter:
+		return c.broker.DeadLetter(ctx, msg, reason)
+	}
+	return fmt.Errorf("unknown action '%s'", action)
+}
+
+// decodeBody decodes the JSON body of a message, rejecting unknown fields.
+func decodeBody(body []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("could not decode message body: '%s': %w", err, errInvalidBody)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/alesr/resterrdemo/service/foo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+type fooServiceMock struct {
+	createFunc func(ctx context.Context, newFoo foo.Foo) (foo.Foo, error)
+	updateFunc func(ctx context.Context, updated foo.Foo) (foo.Foo, error)
+	deleteFunc func(ctx context.Context, id int64) error
+}
+
+func (m *fooServiceMock) Create(ctx context.Context, newFoo foo.Foo) (foo.Foo, error) {
+	return m.createFunc(ctx, newFoo)
+}
+
+func (m *fooServiceMock) Update(ctx context.Context, updated foo.Foo) (foo.Foo, error) {
+	return m.updateFunc(ctx, updated)
+}
+
+func (m *fooServiceMock) Delete(ctx context.Context, id int64) error {
+	return m.deleteFunc(ctx, id)
+}
+
+type barServiceMock struct {
+	createFunc func(ctx context.Context, newBar bar.Bar) (bar.Bar, error)
+	updateFunc func(ctx context.Context, updated bar.Bar) (bar.Bar, error)
+	deleteFunc func(ctx context.Context, id int64) error
+}
+
+func (m *barServiceMock) Create(ctx context.Context, newBar bar.Bar) (bar.Bar, error) {
+	return m.createFunc(ctx, newBar)
+}
+
+func (m *barServiceMock) Update(ctx context.Context, updated bar.Bar) (bar.Bar, error) {
+	return m.updateFunc(ctx, updated)
+}
+
+func (m *barServiceMock) Delete(ctx context.Context, id int64) error {
+	return m.deleteFunc(ctx, id)
+}
+
+// startConsumer runs a consumer of the broker until the end of the test.
+func startConsumer(t *testing.T, broker *MemoryBroker, fooSvc fooService, barSvc barService, opts ...Option) {
+	t.Helper()
+
+	fooErrHandler, err := NewErrorHandler(noopLogger, FooErrMap)
+	require.NoError(t, err)
+
+	barErrHandler, err := NewErrorHandler(noopLogger, BarErrMap)
+	require.NoError(t, err)
+
+	opts = append([]Option{WithBackoff(Backoff{Initial: time.Millisecond, Max: time.Millisecond})}, opts...)
+	consumer := NewConsumer(noopLogger, broker, fooSvc, fooErrHandler, barSvc, barErrHandler, opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		broker.Close()
+	})
+}
+
+// waitSettled waits until n messages are acknowledged or dead-lettered.
+func waitSettled(t *testing.T, broker *MemoryBroker, n int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return len(broker.Acked())+len(broker.DeadLetters()) >= n
+	}, time.Second, time.Millisecond)
+}
+
+func TestConsumer_Run(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		message            Message
+		fooSvc             fooServiceMock
+		barSvc             barServiceMock
+		expectedAck        bool
+		expectedAttempts   int
+		expectedDeadLetter error
+	}{
+		{
+			name:    "foo created",
+			message: Message{Type: TypeFooCreate, Body: []byte(`{"name": "foo"}`)},
+			fooSvc: fooServiceMock{
+				createFunc: func(ctx context.Context, newFoo foo.Foo) (foo.Foo, error) {
+					return foo.Foo{ID: 1, Name: newFoo.Name}, nil
+				},
+			},
+			expectedAck:      true,
+			expectedAttempts: 1,
+		},
+		{
+			name:               "invalid body",
+			message:            Message{Type: TypeFooCreate, Body: []byte(`{"name": 1}`)},
+			expectedAttempts:   1,
+			expectedDeadLetter: errInvalidBody,
+		},
+		{
+			name:    "invalid foo",
+			message: Message{Type: TypeFooUpdate, Body: []byte(`{"id": 1, "name": ""}`)},
+			fooSvc: fooServiceMock{
+				updateFunc: func(ctx context.Context, updated foo.Foo) (foo.Foo, error) {
+					return foo.Foo{}, &domainerr.ValidationError{Err: foo.ErrInvalidFoo}
+				},
+			},
+			expectedAttempts:   1,
+			expectedDeadLetter: foo.ErrInvalidFoo,
+		},
+		{
+			name:    "bar unavailable then deleted",
+			message: Message{Type: TypeBarDelete, Body: []byte(`{"id": 1}`)},
+			barSvc: barServiceMock{
+				deleteFunc: failing(2, fmt.Errorf("could not delete bar: %w", bar.ErrBarUnavailable)),
+			},
+			expectedAck:      true,
+			expectedAttempts: 3,
+		},
+		{
+			name:    "bar unavailable until retries are exhausted",
+			message: Message{Type: TypeBarDelete, Body: []byte(`{"id": 1}`)},
+			barSvc: barServiceMock{
+				deleteFunc: failing(DefaultMaxAttempts, fmt.Errorf("could not delete bar: %w", bar.ErrBarUnavailable)),
+			},
+			expectedAttempts:   DefaultMaxAttempts,
+			expectedDeadLetter: bar.ErrBarUnavailable,
+		},
+		{
+			name:    "bar name taken",
+			message: Message{Type: TypeBarCreate, Body: []byte(`{"name": "bar"}`)},
+			barSvc: barServiceMock{
+				createFunc: func(ctx context.Context, newBar bar.Bar) (bar.Bar, error) {
+					return bar.Bar{}, fmt.Errorf("could not create bar: %w", bar.ErrBarNameTaken)
+				},
+			},
+			expectedAttempts:   1,
+			expectedDeadLetter: bar.ErrBarNameTaken,
+		},
+		{
+			name:               "unknown type",
+			message:            Message{Type: "baz.create", Body: []byte(`{}`)},
+			expectedAttempts:   1,
+			expectedDeadLetter: errUnknownType,
+		},
+		{
+			name:    "handler panic",
+			message: Message{Type: TypeFooDelete, Body: []byte(`{"id": 1}`)},
+			fooSvc: fooServiceMock{
+				deleteFunc: func(ctx context.Context, id int64) error {
+					panic("boom")
+				},
+			},
+			expectedAttempts:   1,
+			expectedDeadLetter: errPanic,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			broker := NewMemoryBroker()
+			startConsumer(t, broker, &tc.fooSvc, &tc.barSvc)
+
+			require.NoError(t, broker.Publish(tc.message))
+			waitSettled(t, broker, 1)
+
+			if tc.expectedAck {
+				require.Len(t, broker.Acked(), 1)
+				assert.Empty(t, broker.DeadLetters())
+				assert.Equal(t, tc.expectedAttempts, broker.Acked()[0].Attempt)
+				return
+			}
+
+			require.Len(t, broker.DeadLetters(), 1)
+			assert.Empty(t, broker.Acked())
+			assert.Equal(t, tc.expectedAttempts, broker.DeadLetters()[0].Message.Attempt)
+			assert.ErrorIs(t, broker.DeadLetters()[0].Reason, tc.expectedDeadLetter)
+		})
+	}
+}
+
+// failing returns a delete function failing with err the first n times it is called.
+func failing(n int, err error) func(ctx context.Context, id int64) error {
+	var calls atomic.Int32
+	return func(ctx context.Context, id int64) error {
+		if int(calls.Add(1)) <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestConsumer_Run_StoppedOnLastAttempt(t *testing.T) {
+	t.Parallel()
+
+	fooErrHandler, err := NewErrorHandler(noopLogger, FooErrMap)
+	require.NoError(t, err)
+
+	barErrHandler, err := NewErrorHandler(noopLogger, BarErrMap)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	barSvc := barServiceMock{
+		deleteFunc: func(ctx context.Context, id int64) error {
+			close(started)
+			<-ctx.Done()
+			return fmt.Errorf("could not delete bar: %w", ctx.Err())
+		},
+	}
+
+	broker := NewMemoryBroker()
+	defer broker.Close()
+
+	consumer := NewConsumer(
+		noopLogger, broker, &fooServiceMock{}, fooErrHandler, &barSvc, barErrHandler,
+		WithBackoff(Backoff{Initial: time.Millisecond, Max: time.Millisecond}),
+		WithMaxAttempts(1),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	require.NoError(t, broker.Publish(Message{Type: TypeBarDelete, Body: []byte(`{"id": 1}`)}))
+	<-started
+	cancel()
+	require.NoError(t, <-done)
+
+	// The message is delivered again to the next consumer, instead of being dead-lettered.
+	receiveCtx, cancelReceive := context.WithTimeout(context.Background(), time.Second)
+	defer cancelReceive()
+
+	msg, err := broker.Receive(receiveCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, msg.Attempt)
+	assert.Empty(t, broker.DeadLetters())
+}
+
+func TestConsumer_Run_Tracing(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	broker := NewMemoryBroker()
+	fooSvc := fooServiceMock{
+		createFunc: func(ctx context.Context, newFoo foo.Foo) (foo.Foo, error) {
+			return foo.Foo{}, &domainerr.ValidationError{Err: foo.ErrInvalidFoo}
+		},
+	}
+	startConsumer(t, broker, &fooSvc, &barServiceMock{}, WithTracerProvider(tp))
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	require.NoError(t, broker.Publish(Message{
+		Type:    TypeFooCreate,
+		Body:    []byte(`{"name": ""}`),
+		Headers: map[string]string{"traceparent": traceparent},
+	}))
+	waitSettled(t, broker, 1)
+
+	require.Eventually(t, func() bool { return len(exporter.GetSpans()) == 1 }, time.Second, time.Millisecond)
+
+	span := exporter.GetSpans()[0]
+	assert.Equal(t, "foo.create process", span.Name)
+	assert.Equal(t, trace.SpanKindConsumer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.Parent.TraceID().String())
+	assert.Contains(t, span.Attributes, attribute.String("error.type", "foo.invalid"))
+	assert.Contains(t, span.Attributes, attribute.String("queue.action", "dead-letter"))
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	t.Parallel()
+
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: 100 * time.Millisecond},
+		{attempt: 2, expected: 200 * time.Millisecond},
+		{attempt: 4, expected: 800 * time.Millisecond},
+		{attempt: 5, expected: time.Second},
+		{attempt: 100, expected: time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.attempt), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, b.Delay(tc.attempt))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alesr/resterrdemo/app/rest/problem"
+)
+
+var (
+	errRouteNotFound    = errors.New("route not found")
+	errMethodNotAllowed = errors.New("method not allowed")
+	errNotReady         = errors.New("not ready")
+)
+
+// Problem types of the requests that are not routed to any resource.
+const (
+	typeRouteNotFound    = "https://api.resterrdemo.example/problems/route-not-found"
+	typeMethodNotAllowed = "https://api.resterrdemo.example/problems/method-not-allowed"
+	typeNotReady         = "https://api.resterrdemo.example/problems/not-ready"
+)
+
+// errMap is the mapping between the errors of the requests that are not handled by a resource,
+// such as the ones not matching any route, and the problem details sent back,
+// in the same format as the errors of the resources.
+var errMap = problem.Map{
+	{
+		Err: errRouteNotFound,
+		Value: problem.Problem{
+			Type:   typeRouteNotFound,
+			Status: http.StatusNotFound,
+			Detail: "no resource is served at this path",
+		},
+	},
+	{
+		Err: errMethodNotAllowed,
+		Value: problem.Problem{
+			Type:   typeMethodNotAllowed,
+			Status: http.StatusMethodNotAllowed,
+			Detail: "the resource does not support this method",
+		},
+	},
+	{
+		Err: errNotReady,
+		Value: problem.Problem{
+			Type:   typeNotReady,
+			Status: http.StatusServiceUnavailable,
+			Detail: "the application is not ready to serve requests",
+		},
+	},
+}
@@ -2,19 +2,47 @@ package bar
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+
+	"github.com/alesr/resterrdemo/service/bar"
+)
+
+var (
+	// errInvalidID is returned when the ID in the request path is not a valid bar ID.
+	errInvalidID = errors.New("invalid bar id")
+
+	// errInvalidBody is returned when the request body cannot be decoded.
+	errInvalidBody = errors.New("invalid bar request body")
 )
 
 type barService interface {
-	Fetch() error
+	Fetch(ctx context.Context, id int64) (bar.Bar, error)
+	List(ctx context.Context) ([]bar.Bar, error)
+	Create(ctx context.Context, newBar bar.Bar) (bar.Bar, error)
+	Update(ctx context.Context, updated bar.Bar) (bar.Bar, error)
+	Patch(ctx context.Context, id int64, patch bar.BarPatch) (bar.Bar, error)
+	Delete(ctx context.Context, id int64) error
 }
 
 type errHandler interface {
 	Handle(ctx context.Context, w http.ResponseWriter, err error)
 }
 
+// barRequest is the body accepted when creating or replacing a bar resource.
+type barRequest struct {
+	Name string `json:"name"`
+}
+
+// barPatchRequest is the body accepted when partially updating a bar resource.
+type barPatchRequest struct {
+	Name *string `json:"name"`
+}
+
 // BarHandler implements HTTP handlers and processes requests related to the bar resource.
 type BarHandler struct {
 	logger     *slog.Logger
@@ -31,11 +59,132 @@ func NewHandler(logger *slog.Logger, barSvc barService, errHandler errHandler) (
 	}, nil
 }
 
-// Get mimics an HTTP handler for fetching a bar resource.
+// Get fetches the bar resource identified by the ID in the request path.
 func (bh *BarHandler) Get(w http.ResponseWriter, r *http.Request) {
-	if err := bh.barSvc.Fetch(); err != nil {
+	id, err := parseID(r)
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := bh.barSvc.Fetch(r.Context(), id)
+	if err != nil {
 		bh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not get bar from service: %w", err))
 		return
 	}
-	w.WriteHeader(http.StatusTeapot)
+	bh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// List fetches all bar resources.
+func (bh *BarHandler) List(w http.ResponseWriter, r *http.Request) {
+	res, err := bh.barSvc.List(r.Context())
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not list bar from service: %w", err))
+		return
+	}
+	bh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// Create creates a new bar resource from the request body.
+func (bh *BarHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req barRequest
+	if err := decodeBody(r, &req); err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := bh.barSvc.Create(r.Context(), bar.Bar{Name: req.Name})
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not create bar from service: %w", err))
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, res.ID))
+	bh.writeJSON(r.Context(), w, http.StatusCreated, res)
+}
+
+// Update replaces the bar resource identified by the ID in the request path.
+func (bh *BarHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	var req barRequest
+	if err := decodeBody(r, &req); err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := bh.barSvc.Update(r.Context(), bar.Bar{ID: id, Name: req.Name})
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not update bar from service: %w", err))
+		return
+	}
+	bh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// Patch partially updates the bar resource identified by the ID in the request path.
+func (bh *BarHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	var req barPatchRequest
+	if err := decodeBody(r, &req); err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := bh.barSvc.Patch(r.Context(), id, bar.BarPatch{Name: req.Name})
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not patch bar from service: %w", err))
+		return
+	}
+	bh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// Delete deletes the bar resource identified by the ID in the request path.
+func (bh *BarHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		bh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	if err := bh.barSvc.Delete(r.Context(), id); err != nil {
+		bh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not delete bar from service: %w", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (bh *BarHandler) writeJSON(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		bh.logger.ErrorContext(ctx, "Failed to write JSON response.", slog.String("error", err.Error()))
+	}
+}
+
+func parseID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse bar id '%s': %w", r.PathValue("id"), errInvalidID)
+	}
+	return id, nil
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("could not decode bar request body: '%s': %w", err, errInvalidBody)
+	}
+	return nil
 }
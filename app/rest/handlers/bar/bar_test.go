@@ -2,21 +2,57 @@ package bar
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/domainerr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
 type barServiceMock struct {
-	fetchFunc func() error
+	fetchFunc  func(ctx context.Context, id int64) (bar.Bar, error)
+	listFunc   func(ctx context.Context) ([]bar.Bar, error)
+	createFunc func(ctx context.Context, newBar bar.Bar) (bar.Bar, error)
+	updateFunc func(ctx context.Context, updated bar.Bar) (bar.Bar, error)
+	patchFunc  func(ctx context.Context, id int64, patch bar.BarPatch) (bar.Bar, error)
+	deleteFunc func(ctx context.Context, id int64) error
+}
+
+func (m *barServiceMock) Fetch(ctx context.Context, id int64) (bar.Bar, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *barServiceMock) List(ctx context.Context) ([]bar.Bar, error) {
+	return m.listFunc(ctx)
+}
+
+func (m *barServiceMock) Create(ctx context.Context, newBar bar.Bar) (bar.Bar, error) {
+	return m.createFunc(ctx, newBar)
 }
 
-func (m *barServiceMock) Fetch() error {
-	return m.fetchFunc()
+func (m *barServiceMock) Update(ctx context.Context, updated bar.Bar) (bar.Bar, error) {
+	return m.updateFunc(ctx, updated)
+}
+
+func (m *barServiceMock) Patch(ctx context.Context, id int64, patch bar.BarPatch) (bar.Bar, error) {
+	return m.patchFunc(ctx, id, patch)
+}
+
+func (m *barServiceMock) Delete(ctx context.Context, id int64) error {
+	return m.deleteFunc(ctx, id)
 }
 
 type errHandlerMock struct {
@@ -31,9 +67,10 @@ func TestNewHandler(t *testing.T) {
 	t.Parallel()
 
 	svc := &barServiceMock{}
+
 	errHandler := &errHandlerMock{}
 
-	handler, err := NewHandler(slog.Default(), svc, errHandler)
+	handler, err := NewHandler(noopLogger, svc, errHandler)
 
 	require.NoError(t, err)
 	require.NotNil(t, handler)
@@ -47,34 +84,112 @@ func TestBarHandler_Get(t *testing.T) {
 
 	testCases := []struct {
 		name            string
+		givenID         string
+		serviceBar      bar.Bar
 		serviceError    error
 		expectedStatus  int
+		expectedBody    string
+		expectedError   error
 		wasErrorHandled bool
 	}{
 		{
-			name:            "service returns no error",
-			serviceError:    nil,
-			expectedStatus:  http.StatusTeapot,
-			wasErrorHandled: false,
+			name:           "service returns bar",
+			givenID:        "42",
+			serviceBar:     bar.Bar{ID: 42, Name: "bar"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"bar"}`,
+		},
+		{
+			name:            "invalid id",
+			givenID:         "not-an-id",
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidID,
+			wasErrorHandled: true,
 		},
 		{
 			name:            "service returns error",
+			givenID:         "42",
 			serviceError:    assert.AnError,
 			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
 			wasErrorHandled: true,
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc // capture the range variable
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
 			var errorHandled bool
 
 			svc := barServiceMock{
-				fetchFunc: func() error {
-					return tc.serviceError
+				fetchFunc: func(ctx context.Context, id int64) (bar.Bar, error) {
+					assert.Equal(t, int64(42), id)
+					return tc.serviceBar, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/bar/"+tc.givenID, nil)
+			req.SetPathValue("id", tc.givenID)
+			w := httptest.NewRecorder()
+
+			handler.Get(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBarHandler_List(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		serviceBars     []bar.Bar
+		serviceError    error
+		expectedStatus  int
+		expectedBody    string
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service returns bars",
+			serviceBars:    []bar.Bar{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"id":1,"name":"first"},{"id":2,"name":"second"}]`,
+		},
+		{
+			name:            "service returns error",
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := barServiceMock{
+				listFunc: func(ctx context.Context) ([]bar.Bar, error) {
+					return tc.serviceBars, tc.serviceError
 				},
 			}
 
@@ -86,16 +201,652 @@ func TestBarHandler_Get(t *testing.T) {
 				},
 			}
 
-			handler, err := NewHandler(slog.Default(), &svc, &errHandler)
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
 			require.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodGet, "/bar", nil)
 			w := httptest.NewRecorder()
 
-			handler.Get(w, req)
+			handler.List(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
 			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBarHandler_Create(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		givenBody        string
+		serviceError     error
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+		expectedError    error
+		wasErrorHandled  bool
+	}{
+		{
+			name:             "service creates bar",
+			givenBody:        `{"name":"bar"}`,
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     `{"id":42,"name":"bar"}`,
+			expectedLocation: "/bar/42",
+		},
+		{
+			name:            "invalid body",
+			givenBody:       `{"name":`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "unknown field",
+			givenBody:       `{"id":1,"name":"bar"}`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenBody:       `{"name":"bar"}`,
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := barServiceMock{
+				createFunc: func(ctx context.Context, newBar bar.Bar) (bar.Bar, error) {
+					assert.Equal(t, bar.Bar{Name: "bar"}, newBar)
+
+					newBar.ID = 42
+					return newBar, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/bar", strings.NewReader(tc.givenBody))
+			w := httptest.NewRecorder()
+
+			handler.Create(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBarHandler_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		givenID         string
+		givenBody       string
+		serviceError    error
+		expectedStatus  int
+		expectedBody    string
+		expectedError   error
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service updates bar",
+			givenID:        "42",
+			givenBody:      `{"name":"bar"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"bar"}`,
+		},
+		{
+			name:            "invalid id",
+			givenID:         "not-an-id",
+			givenBody:       `{"name":"bar"}`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidID,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "invalid body",
+			givenID:         "42",
+			givenBody:       `[]`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenID:         "42",
+			givenBody:       `{"name":"bar"}`,
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := barServiceMock{
+				updateFunc: func(ctx context.Context, updated bar.Bar) (bar.Bar, error) {
+					assert.Equal(t, bar.Bar{ID: 42, Name: "bar"}, updated)
+					return updated, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPut, "/bar/"+tc.givenID, strings.NewReader(tc.givenBody))
+			req.SetPathValue("id", tc.givenID)
+			w := httptest.NewRecorder()
+
+			handler.Update(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBarHandler_Patch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		givenBody       string
+		expectedPatch   bar.BarPatch
+		serviceError    error
+		expectedStatus  int
+		expectedBody    string
+		expectedError   error
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service patches bar",
+			givenBody:      `{"name":"patched"}`,
+			expectedPatch:  bar.BarPatch{Name: ptr("patched")},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"patched"}`,
+		},
+		{
+			name:           "empty patch",
+			givenBody:      `{}`,
+			expectedPatch:  bar.BarPatch{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"bar"}`,
+		},
+		{
+			name:            "invalid body",
+			givenBody:       `{"name":1}`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenBody:       `{"name":"patched"}`,
+			expectedPatch:   bar.BarPatch{Name: ptr("patched")},
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := barServiceMock{
+				patchFunc: func(ctx context.Context, id int64, patch bar.BarPatch) (bar.Bar, error) {
+					assert.Equal(t, int64(42), id)
+					assert.Equal(t, tc.expectedPatch, patch)
+
+					patched := bar.Bar{ID: id, Name: "bar"}
+					if patch.Name != nil {
+						patched.Name = *patch.Name
+					}
+					return patched, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPatch, "/bar/42", strings.NewReader(tc.givenBody))
+			req.SetPathValue("id", "42")
+			w := httptest.NewRecorder()
+
+			handler.Patch(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBarHandler_Delete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		givenID         string
+		serviceError    error
+		expectedStatus  int
+		expectedError   error
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service deletes bar",
+			givenID:        "42",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:            "invalid id",
+			givenID:         "",
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidID,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenID:         "42",
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := barServiceMock{
+				deleteFunc: func(ctx context.Context, id int64) error {
+					assert.Equal(t, int64(42), id)
+					return tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodDelete, "/bar/"+tc.givenID, nil)
+			req.SetPathValue("id", tc.givenID)
+			w := httptest.NewRecorder()
+
+			handler.Delete(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+		})
+	}
+}
+
+// barRepoMock is a bar repository failing every operation with err, except the ones that cannot fail that way:
+// like the PostgreSQL repository, only the operations on a given ID report a missing bar,
+// and only the ones writing a bar report a duplicate one.
+type barRepoMock struct {
+	err error
+}
+
+func (m *barRepoMock) Fetch(_ context.Context, id int64) (bar.Bar, error) {
+	return bar.Bar{ID: id, Name: "bar"}, m.errFor(true, false)
+}
+
+func (m *barRepoMock) List(context.Context) ([]bar.Bar, error) { return nil, m.errFor(false, false) }
+
+func (m *barRepoMock) Create(_ context.Context, b bar.Bar) (bar.Bar, error) {
+	return b, m.errFor(false, true)
+}
+
+func (m *barRepoMock) Update(_ context.Context, b bar.Bar) (bar.Bar, error) {
+	return b, m.errFor(true, true)
+}
+
+func (m *barRepoMock) Delete(context.Context, int64) error { return m.errFor(true, false) }
+
+// errFor returns the error of an operation, given whether it operates on a given ID and whether it writes a bar.
+func (m *barRepoMock) errFor(byID, writes bool) error {
+	switch {
+	case !byID && errors.Is(m.err, bar.ErrBarNotFound):
+		return nil
+	case !writes && errors.Is(m.err, bar.ErrBarDuplicate):
+		return nil
+	default:
+		return m.err
+	}
+}
+
+// TestBarHandler_MissingBar pins the status of a missing bar for every operation on a bar,
+// each of them reporting it as not found.
+func TestBarHandler_MissingBar(t *testing.T) {
+	t.Parallel()
+
+	errHandler, err := problem.NewHandler(noopLogger, ErrMap, problem.WithKindDefaults(bar.Errors))
+	require.NoError(t, err)
+
+	handler, err := NewHandler(noopLogger, bar.New(&barRepoMock{err: bar.ErrBarNotFound}), errHandler)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		method         string
+		serve          http.HandlerFunc
+		expectedStatus int
+		expectedType   string
+	}{
+		{method: http.MethodGet, serve: handler.Get, expectedStatus: http.StatusNotFound, expectedType: problemtype.BarNotFound},
+		{method: http.MethodPut, serve: handler.Update, expectedStatus: http.StatusNotFound, expectedType: problemtype.BarNotFound},
+		{method: http.MethodPatch, serve: handler.Patch, expectedStatus: http.StatusNotFound, expectedType: problemtype.BarNotFound},
+		{method: http.MethodDelete, serve: handler.Delete, expectedStatus: http.StatusNotFound, expectedType: problemtype.BarNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tc.method, "/bar/42", strings.NewReader(`{"name":"bar"}`))
+			req.SetPathValue("id", "42")
+			w := httptest.NewRecorder()
+
+			tc.serve(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+
+			var got problem.Problem
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+			assert.Equal(t, tc.expectedType, got.Type)
+		})
+	}
+}
+
+func TestBarHandler_ErrMap(t *testing.T) {
+	t.Parallel()
+
+	errHandler, err := problem.NewHandler(noopLogger, ErrMap)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		given error
+		want  problem.Problem
+	}{
+		{
+			name:  "unmapped error is returned as internal server error",
+			given: bar.ErrBarUnavailable,
+			want: problem.Problem{
+				Type:     problem.BlankType,
+				Status:   http.StatusInternalServerError,
+				Title:    "Internal Server Error",
+				Detail:   "something went wrong",
+				Instance: "/bar/42",
+			},
+		},
+		{
+			name:  "canceled context is returned as client closed request",
+			given: fmt.Errorf("could not get bar: %w", context.Canceled),
+			want: problem.Problem{
+				Type:     problemtype.RequestCanceled,
+				Status:   statusClientClosedRequest,
+				Title:    "Client Closed Request",
+				Detail:   "the request was canceled",
+				Instance: "/bar/42",
+			},
+		},
+		{
+			name:  "exceeded deadline is returned as gateway timeout",
+			given: fmt.Errorf("could not get bar: %w", context.DeadlineExceeded),
+			want: problem.Problem{
+				Type:     problemtype.RequestTimeout,
+				Status:   http.StatusGatewayTimeout,
+				Title:    "Gateway Timeout",
+				Detail:   "the request timed out",
+				Instance: "/bar/42",
+			},
+		},
+		{
+			name:  "missing bar is returned as not found",
+			given: bar.ErrNoSuchBar,
+			want: problem.Problem{
+				Type:     problemtype.BarNotFound,
+				Status:   http.StatusNotFound,
+				Title:    "Bar Not Found",
+				Detail:   "bar not found",
+				Instance: "/bar/42",
+			},
+		},
+		{
+			name:  "taken name is returned as conflict",
+			given: bar.ErrBarNameTaken,
+			want: problem.Problem{
+				Type:     problemtype.BarNameTaken,
+				Status:   http.StatusConflict,
+				Title:    "Bar Name Taken",
+				Detail:   "a bar with this name already exists",
+				Instance: "/bar/42",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := problem.NewContext(context.TODO(), "/bar/42")
+
+			w := httptest.NewRecorder()
+			errHandler.Handle(ctx, w, tc.given)
+
+			defer w.Result().Body.Close()
+
+			assert.Equal(t, tc.want.Status, w.Result().StatusCode)
+			assert.Equal(t, problem.ContentType, w.Result().Header.Get("Content-Type"))
+
+			var result problem.Problem
+
+			err := json.NewDecoder(w.Result().Body).Decode(&result)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.want, result)
+		})
+	}
+}
+
+func TestNewErrMatchers(t *testing.T) {
+	t.Parallel()
+
+	errHandler, err := problem.NewHandler(noopLogger, ErrMap, problem.WithMatchers(NewErrMatchers(ErrMap)...))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		given    error
+		expected string
+	}{
+		{
+			name: "validation error lists invalid params",
+			given: fmt.Errorf("could not create bar: %w", &domainerr.ValidationError{
+				Err:        bar.ErrInvalidBar,
+				Violations: []domainerr.FieldViolation{{Field: "name", Reason: "must not be empty"}},
+			}),
+			expected: `{
+				"type": "https://api.resterrdemo.example/problems/bar/invalid",
+				"status": 422,
+				"title": "Invalid Bar",
+				"detail": "the bar name must not be empty",
+				"invalid-params": [{"name": "name", "reason": "must not be empty"}]
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			errHandler.Handle(context.TODO(), w, tc.given)
+
+			assert.JSONEq(t, tc.expected, w.Body.String())
+		})
+	}
+}
+
+func TestBarHandler_ErrMap_MatchesServiceErrors(t *testing.T) {
+	t.Parallel()
+
+	errHandler, err := problem.NewHandler(
+		noopLogger,
+		ErrMap,
+		problem.WithMatchers(NewErrMatchers(ErrMap)...),
+		problem.WithKindDefaults(bar.Errors),
+	)
+	require.NoError(t, err)
+
+	assert.NoError(t, bar.Errors.Validate(errHandler.IsMapped))
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// TestBarHandler_Errors_MatchResponses serves every operation of the handler on top of the bar service,
+// with invalid requests and every error of the repository, and checks that the errors documented for each
+// operation are the ones it responds with. The internal server error, documented for every operation, is left out.
+func TestBarHandler_Errors_MatchResponses(t *testing.T) {
+	t.Parallel()
+
+	var (
+		repoErrs = []error{nil, bar.ErrBarNotFound, bar.ErrBarDuplicate, bar.ErrBarStorageUnavailable, context.Canceled, context.DeadlineExceeded, assert.AnError}
+		noID     = []string{""}
+		ids      = []string{"42", "not-an-id"}
+		noBody   = []string{""}
+		bodies   = []string{`{"name":"bar"}`, `{"name":""}`, `{`}
+	)
+
+	testCases := []struct {
+		operation string
+		serve     func(bh *BarHandler, w http.ResponseWriter, r *http.Request)
+		ids       []string
+		bodies    []string
+	}{
+		{operation: "List", serve: (*BarHandler).List, ids: noID, bodies: noBody},
+		{operation: "Create", serve: (*BarHandler).Create, ids: noID, bodies: bodies},
+		{operation: "Get", serve: (*BarHandler).Get, ids: ids, bodies: noBody},
+		{operation: "Update", serve: (*BarHandler).Update, ids: ids, bodies: bodies},
+		{operation: "Patch", serve: (*BarHandler).Patch, ids: ids, bodies: bodies},
+		{operation: "Delete", serve: (*BarHandler).Delete, ids: ids, bodies: noBody},
+	}
+
+	response := func(p problem.Problem) string { return fmt.Sprintf("%d %s", p.Status, p.Type) }
+
+	errHandler, err := problem.NewHandler(noopLogger, ErrMap, problem.WithMatchers(NewErrMatchers(ErrMap)...), problem.WithKindDefaults(bar.Errors))
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.operation, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				documented = make(map[string]bool)
+				responded  = make(map[string]bool)
+			)
+
+			for _, repoErr := range repoErrs {
+				handler, err := NewHandler(noopLogger, bar.New(&barRepoMock{err: repoErr}), errHandler)
+				require.NoError(t, err)
+
+				for _, e := range handler.Errors(tc.operation) {
+					documented[response(errHandler.Translate(context.TODO(), e))] = true
+				}
+
+				for _, id := range tc.ids {
+					for _, body := range tc.bodies {
+						req := httptest.NewRequest(http.MethodPost, "/bar/"+id, strings.NewReader(body))
+						req.SetPathValue("id", id)
+						w := httptest.NewRecorder()
+
+						tc.serve(handler, w, req)
+
+						if w.Result().StatusCode < http.StatusBadRequest {
+							continue
+						}
+
+						var got problem.Problem
+						require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+						responded[response(got)] = true
+					}
+				}
+			}
+
+			delete(responded, response(problem.Internal()))
+			assert.Equal(t, documented, responded)
 		})
 	}
 }
@@ -1,15 +1,144 @@
 package bar
 
 import (
-	"github.com/alesr/resterr"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/domainerr"
 )
 
-// ErrMap is the mapping between business layer errors (services) and the JSON errors
+// statusClientClosedRequest is the non-standard status code (popularized by nginx)
+// used to report that the client went away before the response was written.
+const statusClientClosedRequest = 499
+
+// ErrMap is the mapping between business layer errors (services) and the problem details
 // we want to send back from the REST API.
 //
 // All expected errors resulting from downstream processing should be mapped here.
 // Errors that are not mapped are sent to the client as a 500 error without details.
-var ErrMap = map[error]resterr.RESTErr{
-	// In this case, we're choosing to not map any errors from the bar service which will
-	// result to errors being translated as 500.
+// When an error chain matches several entries, the first one wins: context errors come first,
+// since they describe the request rather than the resource.
+var ErrMap = problem.Map{
+	{
+		Err: context.Canceled,
+		Value: problem.Problem{
+			Type:   problemtype.RequestCanceled,
+			Status: statusClientClosedRequest,
+			Title:  "Client Closed Request",
+			Detail: "the request was canceled",
+		},
+	},
+	{
+		Err: context.DeadlineExceeded,
+		Value: problem.Problem{
+			Type:   problemtype.RequestTimeout,
+			Status: http.StatusGatewayTimeout,
+			Detail: "the request timed out",
+		},
+	},
+	{
+		Err: errInvalidID,
+		Value: problem.Problem{
+			Type:   problemtype.BarInvalidID,
+			Status: http.StatusBadRequest,
+			Title:  "Invalid Bar ID",
+			Detail: "the bar id must be an integer",
+		},
+	},
+	{
+		Err: errInvalidBody,
+		Value: problem.Problem{
+			Type:   problemtype.BarInvalidBody,
+			Status: http.StatusBadRequest,
+			Title:  "Invalid Bar Request Body",
+			Detail: "the request body must be a valid bar JSON document",
+		},
+	},
+	{
+		Err: bar.ErrNoSuchBar,
+		Value: problem.Problem{
+			Type:   problemtype.BarNotFound,
+			Status: http.StatusNotFound,
+			Title:  "Bar Not Found",
+			Detail: "bar not found",
+		},
+	},
+	{
+		Err: bar.ErrInvalidBar,
+		Value: problem.Problem{
+			Type:   problemtype.BarInvalid,
+			Status: http.StatusUnprocessableEntity,
+			Title:  "Invalid Bar",
+			Detail: "the bar name must not be empty",
+		},
+	},
+	{
+		Err: bar.ErrBarNameTaken,
+		Value: problem.Problem{
+			Type:   problemtype.BarNameTaken,
+			Status: http.StatusConflict,
+			Title:  "Bar Name Taken",
+			Detail: "a bar with this name already exists",
+		},
+	},
+
+	// bar.ErrBarUnavailable is declared hidden by the bar service,
+	// so it is not mapped and results in errors being translated as 500.
+}
+
+// NewErrMatchers returns the matchers translating the errors carrying data, which cannot be mapped
+// by identity in the error map. They build on the entry of the sentinel they wrap in the error map
+// they are given, adding the data of the matched error. Errors whose sentinel is not in the error map are declined.
+func NewErrMatchers(m problem.Map) []problem.Matcher {
+	return []problem.Matcher{
+		problem.As(func(e *domainerr.ValidationError) (problem.Problem, bool) {
+			if !errors.Is(e, bar.ErrInvalidBar) {
+				return problem.Problem{}, false
+			}
+
+			p, ok := m.Lookup(bar.ErrInvalidBar)
+			if !ok {
+				return problem.Problem{}, false
+			}
+
+			invalidParams := make([]map[string]string, 0, len(e.Violations))
+			for _, v := range e.Violations {
+				invalidParams = append(invalidParams, map[string]string{"name": v.Field, "reason": v.Reason})
+			}
+
+			p.Extensions = map[string]any{"invalid-params": invalidParams}
+			return p, true
+		}),
+	}
+}
+
+// operationErrors are examples of the errors each operation of the handler fails with, documenting
+// its error responses. Errors carrying data are given with sample data, to document the problems of the matchers.
+var operationErrors = func() map[string][]error {
+	var (
+		requestErrs = []error{context.Canceled, context.DeadlineExceeded}
+		invalidBar  = &domainerr.ValidationError{
+			Err:        bar.ErrInvalidBar,
+			Violations: []domainerr.FieldViolation{{Field: "name", Reason: "must not be empty"}},
+		}
+	)
+
+	return map[string][]error{
+		"List":   requestErrs,
+		"Create": append([]error{errInvalidBody, invalidBar, bar.ErrBarNameTaken}, requestErrs...),
+		"Get":    append([]error{errInvalidID, bar.ErrNoSuchBar}, requestErrs...),
+		"Update": append([]error{errInvalidID, errInvalidBody, invalidBar, bar.ErrNoSuchBar, bar.ErrBarNameTaken}, requestErrs...),
+		"Patch":  append([]error{errInvalidID, errInvalidBody, invalidBar, bar.ErrNoSuchBar, bar.ErrBarNameTaken}, requestErrs...),
+		"Delete": append([]error{errInvalidID, bar.ErrNoSuchBar}, requestErrs...),
+	}
+}()
+
+// Errors returns examples of the errors the operation fails with, the operations being named
+// after the methods of the handler (e.g. "Get").
+func (bh *BarHandler) Errors(operation string) []error {
+	return operationErrors[operation]
 }
@@ -1,20 +1,205 @@
 package foo
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
-	"github.com/alesr/resterr"
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/service/domainerr"
 	"github.com/alesr/resterrdemo/service/foo"
 )
 
-// ErrMap is the mapping between business layer errors (services) and the JSON errors
+// statusClientClosedRequest is the non-standard status code (popularized by nginx)
+// used to report that the client went away before the response was written.
+const statusClientClosedRequest = 499
+
+// ErrMap is the mapping between business layer errors (services) and the problem details
 // we want to send back from the REST API.
 //
 // All expected errors resulting from downstream processing should be mapped here.
 // Errors that are not mapped are sent to the client as a 500 error without details.
-var ErrMap = map[error]resterr.RESTErr{
-	foo.ErrGetFaleid: {
-		StatusCode: http.StatusTeapot,
-		Message:    "could not perform the get foo operation",
+// When an error chain matches several entries, the first one wins: context errors come first,
+// since they describe the request rather than the resource.
+var ErrMap = problem.Map{
+	{
+		Err: context.Canceled,
+		Value: problem.Problem{
+			Type:   problemtype.RequestCanceled,
+			Status: statusClientClosedRequest,
+			Title:  "Client Closed Request",
+			Detail: "the request was canceled",
+		},
+	},
+	{
+		Err: context.DeadlineExceeded,
+		Value: problem.Problem{
+			Type:   problemtype.RequestTimeout,
+			Status: http.StatusGatewayTimeout,
+			Detail: "the request timed out",
+		},
+	},
+	{
+		Err: errInvalidID,
+		Value: problem.Problem{
+			Type:   problemtype.FooInvalidID,
+			Status: http.StatusBadRequest,
+			Title:  "Invalid Foo ID",
+			Detail: "the foo id must be an integer",
+		},
+	},
+	{
+		Err: errInvalidBody,
+		Value: problem.Problem{
+			Type:   problemtype.FooInvalidBody,
+			Status: http.StatusBadRequest,
+			Title:  "Invalid Foo Request Body",
+			Detail: "the request body must be a valid foo JSON document",
+		},
+	},
+	{
+		Err: foo.ErrFooNotFound,
+		Value: problem.Problem{
+			Type:   problemtype.FooNotFound,
+			Status: http.StatusNotFound,
+			Title:  "Foo Not Found",
+			Detail: "foo not found",
+		},
+	},
+	{
+		Err: foo.ErrInvalidFoo,
+		Value: problem.Problem{
+			Type:   problemtype.FooInvalid,
+			Status: http.StatusUnprocessableEntity,
+			Title:  "Invalid Foo",
+			Detail: "the foo name must not be empty",
+		},
 	},
+	{
+		Err: foo.ErrGetFaleid,
+		Value: problem.Problem{
+			Type:   problemtype.FooGetFailed,
+			Status: http.StatusTeapot,
+			Title:  "Foo Get Failed",
+			Detail: "could not perform the get foo operation",
+		},
+	},
+	{
+		Err: foo.ErrListFailed,
+		Value: problem.Problem{
+			Type:   problemtype.FooListFailed,
+			Status: http.StatusServiceUnavailable,
+			Title:  "Foo List Failed",
+			Detail: "could not perform the list foo operation",
+		},
+	},
+	{
+		Err: foo.ErrCreateFailed,
+		Value: problem.Problem{
+			Type:   problemtype.FooCreateFailed,
+			Status: http.StatusServiceUnavailable,
+			Title:  "Foo Create Failed",
+			Detail: "could not perform the create foo operation",
+		},
+	},
+	{
+		Err: foo.ErrUpdateFailed,
+		Value: problem.Problem{
+			Type:   problemtype.FooUpdateFailed,
+			Status: http.StatusServiceUnavailable,
+			Title:  "Foo Update Failed",
+			Detail: "could not perform the update foo operation",
+		},
+	},
+	{
+		Err: foo.ErrDeleteFailed,
+		Value: problem.Problem{
+			Type:   problemtype.FooDeleteFailed,
+			Status: http.StatusServiceUnavailable,
+			Title:  "Foo Delete Failed",
+			Detail: "could not perform the delete foo operation",
+		},
+	},
+}
+
+// ErrMapV2 is the mapping of the version 2 of the API. It differs from ErrMap (version 1)
+// in reporting the failures to get a foo as the service being unavailable, which they are,
+// rather than with the teapot status version 1 clients rely on.
+var ErrMapV2 = ErrMap.With(foo.ErrGetFaleid, problem.Problem{
+	Type:   problemtype.FooGetFailed,
+	Status: http.StatusServiceUnavailable,
+	Title:  "Foo Get Failed",
+	Detail: "could not perform the get foo operation",
+})
+
+// NewErrMatchers returns the matchers translating the errors carrying data, which cannot be mapped
+// by identity in the error map. They build on the entry of the sentinel they wrap in the error map
+// they are given, adding the data of the matched error, so that each version of the API keeps its own problems.
+// Errors whose sentinel is not in the error map are declined.
+func NewErrMatchers(m problem.Map) []problem.Matcher {
+	return []problem.Matcher{
+		problem.As(func(e *domainerr.ValidationError) (problem.Problem, bool) {
+			if !errors.Is(e, foo.ErrInvalidFoo) {
+				return problem.Problem{}, false
+			}
+
+			p, ok := m.Lookup(foo.ErrInvalidFoo)
+			if !ok {
+				return problem.Problem{}, false
+			}
+
+			invalidParams := make([]map[string]string, 0, len(e.Violations))
+			for _, v := range e.Violations {
+				invalidParams = append(invalidParams, map[string]string{"name": v.Field, "reason": v.Reason})
+			}
+
+			p.Extensions = map[string]any{"invalid-params": invalidParams}
+			return p, true
+		}),
+		problem.As(func(e *domainerr.Error) (problem.Problem, bool) {
+			id, ok := e.Metadata["id"]
+			if !ok || !errors.Is(e, foo.ErrFooNotFound) {
+				return problem.Problem{}, false
+			}
+
+			p, ok := m.Lookup(foo.ErrFooNotFound)
+			if !ok {
+				return problem.Problem{}, false
+			}
+
+			p.Detail = fmt.Sprintf("foo '%s' not found", id)
+			p.Extensions = map[string]any{"id": id}
+			return p, true
+		}),
+	}
+}
+
+// operationErrors are examples of the errors each operation of the handler fails with, documenting
+// its error responses. Errors carrying data are given with sample data, to document the problems of the matchers.
+var operationErrors = func() map[string][]error {
+	var (
+		requestErrs = []error{context.Canceled, context.DeadlineExceeded}
+		notFound    = foo.ErrFooNotFound.With("id", "42")
+		invalidFoo  = &domainerr.ValidationError{
+			Err:        foo.ErrInvalidFoo,
+			Violations: []domainerr.FieldViolation{{Field: "name", Reason: "must not be empty"}},
+		}
+	)
+
+	return map[string][]error{
+		"List":   append([]error{foo.ErrListFailed}, requestErrs...),
+		"Create": append([]error{errInvalidBody, invalidFoo, foo.ErrCreateFailed}, requestErrs...),
+		"Get":    append([]error{errInvalidID, notFound, foo.ErrGetFaleid}, requestErrs...),
+		"Update": append([]error{errInvalidID, errInvalidBody, invalidFoo, notFound, foo.ErrUpdateFailed}, requestErrs...),
+		"Patch":  append([]error{errInvalidID, errInvalidBody, invalidFoo, notFound, foo.ErrUpdateFailed}, requestErrs...),
+		"Delete": append([]error{errInvalidID, notFound, foo.ErrDeleteFailed}, requestErrs...),
+	}
+}()
+
+// Errors returns examples of the errors the operation fails with, the operations being named
+// after the methods of the handler (e.g. "Get").
+func (fh *FooHandler) Errors(operation string) []error {
+	return operationErrors[operation]
 }
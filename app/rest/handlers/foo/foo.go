@@ -2,19 +2,47 @@ package foo
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+var (
+	// errInvalidID is returned when the ID in the request path is not a valid foo ID.
+	errInvalidID = errors.New("invalid foo id")
+
+	// errInvalidBody is returned when the request body cannot be decoded.
+	errInvalidBody = errors.New("invalid foo request body")
 )
 
 type fooService interface {
-	Fetch() error
+	Fetch(ctx context.Context, id int64) (foo.Foo, error)
+	List(ctx context.Context) ([]foo.Foo, error)
+	Create(ctx context.Context, newFoo foo.Foo) (foo.Foo, error)
+	Update(ctx context.Context, updated foo.Foo) (foo.Foo, error)
+	Patch(ctx context.Context, id int64, patch foo.FooPatch) (foo.Foo, error)
+	Delete(ctx context.Context, id int64) error
 }
 
 type errHandler interface {
 	Handle(ctx context.Context, w http.ResponseWriter, err error)
 }
 
+// fooRequest is the body accepted when creating or replacing a foo resource.
+type fooRequest struct {
+	Name string `json:"name"`
+}
+
+// fooPatchRequest is the body accepted when partially updating a foo resource.
+type fooPatchRequest struct {
+	Name *string `json:"name"`
+}
+
 // FooHandler implements HTTP handlers and processes requests related to the foo resource.
 type FooHandler struct {
 	logger     *slog.Logger
@@ -31,11 +59,132 @@ func NewHandler(logger *slog.Logger, fooSvc fooService, errHandler errHandler) (
 	}, nil
 }
 
-// Get mimics an HTTP handler for fetching a foo resource.
+// Get fetches the foo resource identified by the ID in the request path.
 func (fh *FooHandler) Get(w http.ResponseWriter, r *http.Request) {
-	if err := fh.fooSvc.Fetch(); err != nil {
+	id, err := parseID(r)
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := fh.fooSvc.Fetch(r.Context(), id)
+	if err != nil {
 		fh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not get foo from service: %w", err))
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	fh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// List fetches all foo resources.
+func (fh *FooHandler) List(w http.ResponseWriter, r *http.Request) {
+	res, err := fh.fooSvc.List(r.Context())
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not list foo from service: %w", err))
+		return
+	}
+	fh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// Create creates a new foo resource from the request body.
+func (fh *FooHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req fooRequest
+	if err := decodeBody(r, &req); err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := fh.fooSvc.Create(r.Context(), foo.Foo{Name: req.Name})
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not create foo from service: %w", err))
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, res.ID))
+	fh.writeJSON(r.Context(), w, http.StatusCreated, res)
+}
+
+// Update replaces the foo resource identified by the ID in the request path.
+func (fh *FooHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	var req fooRequest
+	if err := decodeBody(r, &req); err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := fh.fooSvc.Update(r.Context(), foo.Foo{ID: id, Name: req.Name})
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not update foo from service: %w", err))
+		return
+	}
+	fh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// Patch partially updates the foo resource identified by the ID in the request path.
+func (fh *FooHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	var req fooPatchRequest
+	if err := decodeBody(r, &req); err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	res, err := fh.fooSvc.Patch(r.Context(), id, foo.FooPatch{Name: req.Name})
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not patch foo from service: %w", err))
+		return
+	}
+	fh.writeJSON(r.Context(), w, http.StatusOK, res)
+}
+
+// Delete deletes the foo resource identified by the ID in the request path.
+func (fh *FooHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		fh.errHandler.Handle(r.Context(), w, err)
+		return
+	}
+
+	if err := fh.fooSvc.Delete(r.Context(), id); err != nil {
+		fh.errHandler.Handle(r.Context(), w, fmt.Errorf("could not delete foo from service: %w", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fh *FooHandler) writeJSON(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fh.logger.ErrorContext(ctx, "Failed to write JSON response.", slog.String("error", err.Error()))
+	}
+}
+
+func parseID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse foo id '%s': %w", r.PathValue("id"), errInvalidID)
+	}
+	return id, nil
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("could not decode foo request body: '%s': %w", err, errInvalidBody)
+	}
+	return nil
 }
@@ -3,13 +3,18 @@ package foo
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
-	"github.com/alesr/resterr"
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/service/domainerr"
 	"github.com/alesr/resterrdemo/service/foo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,11 +23,36 @@ import (
 var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
 
 type serviceMock struct {
-	fetchFunc func() error
+	fetchFunc  func(ctx context.Context, id int64) (foo.Foo, error)
+	listFunc   func(ctx context.Context) ([]foo.Foo, error)
+	createFunc func(ctx context.Context, newFoo foo.Foo) (foo.Foo, error)
+	updateFunc func(ctx context.Context, updated foo.Foo) (foo.Foo, error)
+	patchFunc  func(ctx context.Context, id int64, patch foo.FooPatch) (foo.Foo, error)
+	deleteFunc func(ctx context.Context, id int64) error
 }
 
-func (m *serviceMock) Fetch() error {
-	return m.fetchFunc()
+func (m *serviceMock) Fetch(ctx context.Context, id int64) (foo.Foo, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *serviceMock) List(ctx context.Context) ([]foo.Foo, error) {
+	return m.listFunc(ctx)
+}
+
+func (m *serviceMock) Create(ctx context.Context, newFoo foo.Foo) (foo.Foo, error) {
+	return m.createFunc(ctx, newFoo)
+}
+
+func (m *serviceMock) Update(ctx context.Context, updated foo.Foo) (foo.Foo, error) {
+	return m.updateFunc(ctx, updated)
+}
+
+func (m *serviceMock) Patch(ctx context.Context, id int64, patch foo.FooPatch) (foo.Foo, error) {
+	return m.patchFunc(ctx, id, patch)
+}
+
+func (m *serviceMock) Delete(ctx context.Context, id int64) error {
+	return m.deleteFunc(ctx, id)
 }
 
 type errHandlerMock struct {
@@ -54,18 +84,34 @@ func TestFooHandler_Get(t *testing.T) {
 
 	testCases := []struct {
 		name            string
+		givenID         string
+		serviceFoo      foo.Foo
 		serviceError    error
 		expectedStatus  int
+		expectedBody    string
+		expectedError   error
 		wasErrorHandled bool
 	}{
 		{
-			name:           "service returns no error",
+			name:           "service returns foo",
+			givenID:        "42",
+			serviceFoo:     foo.Foo{ID: 42, Name: "foo"},
 			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"foo"}`,
+		},
+		{
+			name:            "invalid id",
+			givenID:         "not-an-id",
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidID,
+			wasErrorHandled: true,
 		},
 		{
 			name:            "service returns error",
+			givenID:         "42",
 			serviceError:    assert.AnError,
 			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
 			wasErrorHandled: true,
 		},
 	}
@@ -75,9 +121,75 @@ func TestFooHandler_Get(t *testing.T) {
 			t.Parallel()
 
 			var errorHandled bool
+
 			svc := serviceMock{
-				fetchFunc: func() error {
-					return tc.serviceError
+				fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+					assert.Equal(t, int64(42), id)
+					return tc.serviceFoo, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/foo/"+tc.givenID, nil)
+			req.SetPathValue("id", tc.givenID)
+			w := httptest.NewRecorder()
+
+			handler.Get(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFooHandler_List(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		serviceFoos     []foo.Foo
+		serviceError    error
+		expectedStatus  int
+		expectedBody    string
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service returns foos",
+			serviceFoos:    []foo.Foo{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"id":1,"name":"first"},{"id":2,"name":"second"}]`,
+		},
+		{
+			name:            "service returns error",
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := serviceMock{
+				listFunc: func(ctx context.Context) ([]foo.Foo, error) {
+					return tc.serviceFoos, tc.serviceError
 				},
 			}
 
@@ -95,7 +207,344 @@ func TestFooHandler_Get(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/foo", nil)
 			w := httptest.NewRecorder()
 
-			handler.Get(w, req)
+			handler.List(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFooHandler_Create(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		givenBody        string
+		serviceError     error
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+		expectedError    error
+		wasErrorHandled  bool
+	}{
+		{
+			name:             "service creates foo",
+			givenBody:        `{"name":"foo"}`,
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     `{"id":42,"name":"foo"}`,
+			expectedLocation: "/foo/42",
+		},
+		{
+			name:            "invalid body",
+			givenBody:       `{"name":`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "unknown field",
+			givenBody:       `{"id":1,"name":"foo"}`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenBody:       `{"name":"foo"}`,
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := serviceMock{
+				createFunc: func(ctx context.Context, newFoo foo.Foo) (foo.Foo, error) {
+					assert.Equal(t, foo.Foo{Name: "foo"}, newFoo)
+
+					newFoo.ID = 42
+					return newFoo, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(tc.givenBody))
+			w := httptest.NewRecorder()
+
+			handler.Create(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFooHandler_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		givenID         string
+		givenBody       string
+		serviceError    error
+		expectedStatus  int
+		expectedBody    string
+		expectedError   error
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service updates foo",
+			givenID:        "42",
+			givenBody:      `{"name":"foo"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"foo"}`,
+		},
+		{
+			name:            "invalid id",
+			givenID:         "not-an-id",
+			givenBody:       `{"name":"foo"}`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidID,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "invalid body",
+			givenID:         "42",
+			givenBody:       `[]`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenID:         "42",
+			givenBody:       `{"name":"foo"}`,
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := serviceMock{
+				updateFunc: func(ctx context.Context, updated foo.Foo) (foo.Foo, error) {
+					assert.Equal(t, foo.Foo{ID: 42, Name: "foo"}, updated)
+					return updated, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPut, "/foo/"+tc.givenID, strings.NewReader(tc.givenBody))
+			req.SetPathValue("id", tc.givenID)
+			w := httptest.NewRecorder()
+
+			handler.Update(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFooHandler_Patch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		givenBody       string
+		expectedPatch   foo.FooPatch
+		serviceError    error
+		expectedStatus  int
+		expectedBody    string
+		expectedError   error
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service patches foo",
+			givenBody:      `{"name":"patched"}`,
+			expectedPatch:  foo.FooPatch{Name: ptr("patched")},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"patched"}`,
+		},
+		{
+			name:           "empty patch",
+			givenBody:      `{}`,
+			expectedPatch:  foo.FooPatch{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":42,"name":"foo"}`,
+		},
+		{
+			name:            "invalid body",
+			givenBody:       `{"name":1}`,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidBody,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenBody:       `{"name":"patched"}`,
+			expectedPatch:   foo.FooPatch{Name: ptr("patched")},
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := serviceMock{
+				patchFunc: func(ctx context.Context, id int64, patch foo.FooPatch) (foo.Foo, error) {
+					assert.Equal(t, int64(42), id)
+					assert.Equal(t, tc.expectedPatch, patch)
+
+					patched := foo.Foo{ID: id, Name: "foo"}
+					if patch.Name != nil {
+						patched.Name = *patch.Name
+					}
+					return patched, tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPatch, "/foo/42", strings.NewReader(tc.givenBody))
+			req.SetPathValue("id", "42")
+			w := httptest.NewRecorder()
+
+			handler.Patch(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.wasErrorHandled, errorHandled)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFooHandler_Delete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		givenID         string
+		serviceError    error
+		expectedStatus  int
+		expectedError   error
+		wasErrorHandled bool
+	}{
+		{
+			name:           "service deletes foo",
+			givenID:        "42",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:            "invalid id",
+			givenID:         "",
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   errInvalidID,
+			wasErrorHandled: true,
+		},
+		{
+			name:            "service returns error",
+			givenID:         "42",
+			serviceError:    assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedError:   assert.AnError,
+			wasErrorHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errorHandled bool
+
+			svc := serviceMock{
+				deleteFunc: func(ctx context.Context, id int64) error {
+					assert.Equal(t, int64(42), id)
+					return tc.serviceError
+				},
+			}
+
+			errHandler := errHandlerMock{
+				handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+					errorHandled = true
+					assert.ErrorIs(t, err, tc.expectedError)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			}
+
+			handler, err := NewHandler(noopLogger, &svc, &errHandler)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodDelete, "/foo/"+tc.givenID, nil)
+			req.SetPathValue("id", tc.givenID)
+			w := httptest.NewRecorder()
+
+			handler.Delete(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
 			assert.Equal(t, tc.wasErrorHandled, errorHandled)
@@ -106,28 +555,78 @@ func TestFooHandler_Get(t *testing.T) {
 func TestFooHandler_ErrMap(t *testing.T) {
 	t.Parallel()
 
-	errHandler, err := resterr.NewHandler(noopLogger, ErrMap)
+	errHandler, err := problem.NewHandler(noopLogger, ErrMap)
 	require.NoError(t, err)
 
 	testCases := []struct {
 		name  string
 		given error
-		want  resterr.RESTErr
+		want  problem.Problem
 	}{
 		{
 			name:  "unmapped error is returned as internal server error",
 			given: assert.AnError,
-			want: resterr.RESTErr{
-				StatusCode: http.StatusInternalServerError,
-				Message:    "something went wrong",
+			want: problem.Problem{
+				Type:     problem.BlankType,
+				Status:   http.StatusInternalServerError,
+				Title:    "Internal Server Error",
+				Detail:   "something went wrong",
+				Instance: "/foo/42",
+			},
+		},
+		{
+			name:  "invalid id is returned as bad request",
+			given: errInvalidID,
+			want: problem.Problem{
+				Type:     problemtype.FooInvalidID,
+				Status:   http.StatusBadRequest,
+				Title:    "Invalid Foo ID",
+				Detail:   "the foo id must be an integer",
+				Instance: "/foo/42",
+			},
+		},
+		{
+			name:  "canceled context is returned as client closed request",
+			given: fmt.Errorf("could not get foo: %w", context.Canceled),
+			want: problem.Problem{
+				Type:     problemtype.RequestCanceled,
+				Status:   statusClientClosedRequest,
+				Title:    "Client Closed Request",
+				Detail:   "the request was canceled",
+				Instance: "/foo/42",
+			},
+		},
+		{
+			name:  "exceeded deadline is returned as gateway timeout",
+			given: fmt.Errorf("could not get foo: %w", context.DeadlineExceeded),
+			want: problem.Problem{
+				Type:     problemtype.RequestTimeout,
+				Status:   http.StatusGatewayTimeout,
+				Title:    "Gateway Timeout",
+				Detail:   "the request timed out",
+				Instance: "/foo/42",
+			},
+		},
+		{
+			name:  "missing foo is returned as not found",
+			given: foo.ErrFooNotFound,
+			want: problem.Problem{
+				Type:     problemtype.FooNotFound,
+				Status:   http.StatusNotFound,
+				Title:    "Foo Not Found",
+				Detail:   "foo not found",
+				Instance: "/foo/42",
 			},
 		},
 		{
-			name:  "mapped error is returned as the equivalent JSON error",
+			name:  "mapped error is returned as the equivalent problem",
 			given: foo.ErrGetFaleid,
-			want: resterr.RESTErr{
-				StatusCode: http.StatusTeapot,
-				Message:    "could not perform the get foo operation",
+			want: problem.Problem{
+				Type:     problemtype.FooGetFailed,
+				Status:   http.StatusTeapot,
+				Title:    "Foo Get Failed",
+				Detail:   "could not perform the get foo operation",
+				Instance: "/foo/42",
 			},
 		},
 	}
@@ -136,18 +635,249 @@ func TestFooHandler_ErrMap(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
+			ctx := problem.NewContext(context.TODO(), "/foo/42")
+
 			w := httptest.NewRecorder()
-			errHandler.Handle(context.TODO(), w, tc.given)
+			errHandler.Handle(ctx, w, tc.given)
 
 			defer w.Result().Body.Close()
 
-			var result resterr.RESTErr
+			assert.Equal(t, tc.want.Status, w.Result().StatusCode)
+			assert.Equal(t, problem.ContentType, w.Result().Header.Get("Content-Type"))
+
+			var result problem.Problem
 
 			err := json.NewDecoder(w.Result().Body).Decode(&result)
 			require.NoError(t, err)
 
-			assert.Equal(t, tc.want.StatusCode, result.StatusCode)
-			assert.Equal(t, tc.want.Message, result.Message)
+			assert.Equal(t, tc.want, result)
+		})
+	}
+}
+
+func TestNewErrMatchers(t *testing.T) {
+	t.Parallel()
+
+	// A version of the API reporting the missing foos as gone, whose matchers must not fall back on ErrMap.
+	errMapGone := ErrMap.With(foo.ErrFooNotFound, problem.Problem{
+		Type:   "https://api.resterrdemo.example/problems/foo/gone",
+		Status: http.StatusGone,
+		Title:  "Foo Gone",
+		Detail: "foo gone",
+	})
+
+	testCases := []struct {
+		name     string
+		errMap   problem.Map
+		given    error
+		expected string
+	}{
+		{
+			name:   "validation error lists invalid params",
+			errMap: ErrMap,
+			given: fmt.Errorf("could not create foo: %w", &domainerr.ValidationError{
+				Err:        foo.ErrInvalidFoo,
+				Violations: []domainerr.FieldViolation{{Field: "name", Reason: "must not be empty"}},
+			}),
+			expected: `{
+				"type": "https://api.resterrdemo.example/problems/foo/invalid",
+				"status": 422,
+				"title": "Invalid Foo",
+				"detail": "the foo name must not be empty",
+				"invalid-params": [{"name": "name", "reason": "must not be empty"}]
+			}`,
+		},
+		{
+			name:   "missing foo reports its id",
+			errMap: ErrMap,
+			given:  fmt.Errorf("could not get foo: %w", foo.ErrFooNotFound.With("id", "42")),
+			expected: `{
+				"type": "https://api.resterrdemo.example/problems/foo/not-found",
+				"status": 404,
+				"title": "Foo Not Found",
+				"detail": "foo '42' not found",
+				"id": "42"
+			}`,
+		},
+		{
+			name:   "missing foo reports the problem of the given map",
+			errMap: errMapGone,
+			given:  fmt.Errorf("could not get foo: %w", foo.ErrFooNotFound.With("id", "42")),
+			expected: `{
+				"type": "https://api.resterrdemo.example/problems/foo/gone",
+				"status": 410,
+				"title": "Foo Gone",
+				"detail": "foo '42' not found",
+				"id": "42"
+			}`,
+		},
+		{
+			name:   "sentinel missing from the given map is declined",
+			errMap: problem.Map{},
+			given:  fmt.Errorf("could not get foo: %w", foo.ErrFooNotFound.With("id", "42")),
+			expected: `{
+				"type": "about:blank",
+				"status": 500,
+				"title": "Internal Server Error",
+				"detail": "something went wrong"
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			errHandler, err := problem.NewHandler(noopLogger, tc.errMap, problem.WithMatchers(NewErrMatchers(tc.errMap)...))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			errHandler.Handle(context.TODO(), w, tc.given)
+
+			assert.JSONEq(t, tc.expected, w.Body.String())
+		})
+	}
+}
+
+func TestFooHandler_ErrMap_MatchesServiceErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, errMap := range map[string]problem.Map{"v1": ErrMap, "v2": ErrMapV2} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			errHandler, err := problem.NewHandler(
+				noopLogger,
+				errMap,
+				problem.WithMatchers(NewErrMatchers(errMap)...),
+				problem.WithKindDefaults(foo.Errors),
+			)
+			require.NoError(t, err)
+
+			assert.NoError(t, foo.Errors.Validate(errHandler.IsMapped))
 		})
 	}
 }
+
+func TestFooHandler_ErrMapV2(t *testing.T) {
+	t.Parallel()
+
+	require.Len(t, ErrMapV2, len(ErrMap))
+
+	// Only the status of the get failures changes from the version 1.
+	for i, e := range ErrMapV2 {
+		assert.Equal(t, ErrMap[i].Err, e.Err)
+
+		if e.Err == foo.ErrGetFaleid {
+			assert.Equal(t, http.StatusTeapot, ErrMap[i].Value.Status)
+			assert.Equal(t, http.StatusServiceUnavailable, e.Value.Status)
+			continue
+		}
+		assert.Equal(t, ErrMap[i].Value, e.Value)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// repoMock is a foo repository failing every operation with err, except the ones that cannot fail that way:
+// like the PostgreSQL repository, listing and creating foo entities never report a missing foo.
+type repoMock struct {
+	err error
+}
+
+func (m *repoMock) Fetch(_ context.Context, id int64) (foo.Foo, error) {
+	return foo.Foo{ID: id, Name: "foo"}, m.errFor(true)
+}
+
+func (m *repoMock) List(context.Context) ([]foo.Foo, error) { return nil, m.errFor(false) }
+
+func (m *repoMock) Create(_ context.Context, f foo.Foo) (foo.Foo, error) { return f, m.errFor(false) }
+
+func (m *repoMock) Update(_ context.Context, f foo.Foo) (foo.Foo, error) { return f, m.errFor(true) }
+
+func (m *repoMock) Delete(context.Context, int64) error { return m.errFor(true) }
+
+// errFor returns the error of an operation, which only reports a missing foo when it operates on a given ID.
+func (m *repoMock) errFor(byID bool) error {
+	if !byID && errors.Is(m.err, foo.ErrFooNotFound) {
+		return nil
+	}
+	return m.err
+}
+
+// TestFooHandler_Errors_MatchResponses serves every operation of the handler on top of the foo service,
+// with invalid requests and every error of the repository, and checks that the errors documented for each
+// operation are the ones it responds with. The internal server error, documented for every operation, is left out.
+func TestFooHandler_Errors_MatchResponses(t *testing.T) {
+	t.Parallel()
+
+	var (
+		repoErrs = []error{nil, foo.ErrFooNotFound.With("id", "42"), foo.ErrFooStorageUnavailable, context.Canceled, context.DeadlineExceeded, assert.AnError}
+		noID     = []string{""}
+		ids      = []string{"42", "not-an-id"}
+		noBody   = []string{""}
+		bodies   = []string{`{"name":"foo"}`, `{"name":""}`, `{`}
+	)
+
+	testCases := []struct {
+		operation string
+		serve     func(fh *FooHandler, w http.ResponseWriter, r *http.Request)
+		ids       []string
+		bodies    []string
+	}{
+		{operation: "List", serve: (*FooHandler).List, ids: noID, bodies: noBody},
+		{operation: "Create", serve: (*FooHandler).Create, ids: noID, bodies: bodies},
+		{operation: "Get", serve: (*FooHandler).Get, ids: ids, bodies: noBody},
+		{operation: "Update", serve: (*FooHandler).Update, ids: ids, bodies: bodies},
+		{operation: "Patch", serve: (*FooHandler).Patch, ids: ids, bodies: bodies},
+		{operation: "Delete", serve: (*FooHandler).Delete, ids: ids, bodies: noBody},
+	}
+
+	response := func(p problem.Problem) string { return fmt.Sprintf("%d %s", p.Status, p.Type) }
+
+	for name, errMap := range map[string]problem.Map{"v1": ErrMap, "v2": ErrMapV2} {
+		errHandler, err := problem.NewHandler(noopLogger, errMap, problem.WithMatchers(NewErrMatchers(errMap)...), problem.WithKindDefaults(foo.Errors))
+		require.NoError(t, err)
+
+		for _, tc := range testCases {
+			t.Run(name+" "+tc.operation, func(t *testing.T) {
+				t.Parallel()
+
+				var (
+					documented = make(map[string]bool)
+					responded  = make(map[string]bool)
+				)
+
+				for _, repoErr := range repoErrs {
+					handler, err := NewHandler(noopLogger, foo.New(&repoMock{err: repoErr}), errHandler)
+					require.NoError(t, err)
+
+					for _, e := range handler.Errors(tc.operation) {
+						documented[response(errHandler.Translate(context.TODO(), e))] = true
+					}
+
+					for _, id := range tc.ids {
+						for _, body := range tc.bodies {
+							req := httptest.NewRequest(http.MethodPost, "/foo/"+id, strings.NewReader(body))
+							req.SetPathValue("id", id)
+							w := httptest.NewRecorder()
+
+							tc.serve(handler, w, req)
+
+							if w.Result().StatusCode < http.StatusBadRequest {
+								continue
+							}
+
+							var got problem.Problem
+							require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+							responded[response(got)] = true
+						}
+					}
+				}
+
+				delete(responded, response(problem.Internal()))
+				assert.Equal(t, documented, responded)
+			})
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/alesr/resterrdemo/app/rest/problem"
+)
+
+// Statuses reported by the health endpoints.
+const (
+	statusAlive       = "alive"
+	statusReady       = "ready"
+	statusNotReady    = "not ready"
+	statusAvailable   = "available"
+	statusUnavailable = "unavailable"
+)
+
+// liveness is the body of the liveness check response.
+type liveness struct {
+	Status string `json:"status"`
+}
+
+// readiness is the body of the readiness check response.
+type readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies,omitempty"`
+}
+
+// dependencyStatus is the status of a dependency in the readiness check response.
+// The problem describes why the dependency is unavailable.
+type dependencyStatus struct {
+	Status  string           `json:"status"`
+	Problem *problem.Problem `json:"problem,omitempty"`
+}
+
+// alive reports that the process is alive and serving requests.
+// It does not depend on anything else, so that the process is only restarted when it is stuck.
+func (app *App) alive(w http.ResponseWriter, r *http.Request) {
+	app.writeJSON(r.Context(), w, http.StatusOK, liveness{Status: statusAlive})
+}
+
+// ready reports whether the application is ready to serve requests:
+// it is not shutting down, and all of its dependencies are available.
+// The dependencies are checked concurrently, within the readiness timeout.
+func (app *App) ready(w http.ResponseWriter, r *http.Request) {
+	if app.draining.Load() {
+		app.errHandler.Handle(r.Context(), w, errNotReady)
+		return
+	}
+
+	ctx := r.Context()
+	if app.readinessTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, app.readinessTimeout)
+		defer cancel()
+	}
+
+	statuses := make([]dependencyStatus, len(app.dependencies))
+
+	var wg sync.WaitGroup
+	for i, d := range app.dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = app.check(ctx, d)
+		}()
+	}
+	wg.Wait()
+
+	resp := readiness{Status: statusReady}
+	status := http.StatusOK
+
+	if len(app.dependencies) > 0 {
+		resp.Dependencies = make(map[string]dependencyStatus, len(app.dependencies))
+	}
+
+	for i, d := range app.dependencies {
+		resp.Dependencies[d.name] = statuses[i]
+
+		if statuses[i].Status != statusAvailable {
+			resp.Status = statusNotReady
+			status = http.StatusServiceUnavailable
+		}
+	}
+	app.writeJSON(r.Context(), w, status, resp)
+}
+
+// check pings the dependency, and translates its error, if any, into the problem reported for it.
+func (app *App) check(ctx context.Context, d dependency) dependencyStatus {
+	err := d.pinger.Ping(ctx)
+	if err == nil {
+		return dependencyStatus{Status: statusAvailable}
+	}
+
+	app.logger.WarnContext(ctx, "Dependency unavailable.", slog.String("dependency", d.name), slog.String("error", err.Error()))
+
+	p := d.translator.Translate(ctx, err)
+	return dependencyStatus{Status: statusUnavailable, Problem: &p}
+}
+
+func (app *App) writeJSON(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		app.logger.ErrorContext(ctx, "Failed to write JSON response.", slog.String("error", err.Error()))
+	}
+}
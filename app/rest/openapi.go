@@ -0,0 +1,319 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alesr/resterrdemo/app/rest/problem"
+)
+
+const (
+	// openAPIVersion is the version of the OpenAPI specification the document follows.
+	openAPIVersion = "3.1.0"
+
+	// problemSchemaRef references the schema of the problem details in the document.
+	problemSchemaRef = "#/components/schemas/Problem"
+)
+
+// problemLister is implemented by the error handlers able to list the problems they write and to
+// translate an error into its problem, such as problem.Handler. The error responses of the resources
+// whose error handler is not a problemLister are documented with the internal server error only.
+type problemLister interface {
+	Problems() []problem.Problem
+	Translate(ctx context.Context, err error) problem.Problem
+}
+
+// openAPIDocument is an OpenAPI document, limited to what the application describes.
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       openAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components openAPIComponents                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   map[string]any `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema   map[string]any            `json:"schema,omitempty"`
+	Examples map[string]openAPIExample `json:"examples,omitempty"`
+}
+
+type openAPIExample struct {
+	Summary string          `json:"summary"`
+	Value   problem.Problem `json:"value"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]any `json:"schemas"`
+}
+
+// problemSchema is the schema of the problem details sent by the application.
+var problemSchema = map[string]any{
+	"type":     "object",
+	"required": []string{"type", "status", "title"},
+	"properties": map[string]any{
+		"type":       map[string]any{"type": "string", "format": "uri-reference"},
+		"status":     map[string]any{"type": "integer"},
+		"title":      map[string]any{"type": "string"},
+		"detail":     map[string]any{"type": "string"},
+		"instance":   map[string]any{"type": "string"},
+		"request-id": map[string]any{"type": "string"},
+		"errors":     map[string]any{"type": "array", "items": map[string]any{"$ref": problemSchemaRef}},
+	},
+}
+
+// openAPI generates the OpenAPI document of the application: the routes of its resources and versions,
+// each with the error responses of the errors of the route and the internal server error,
+// and the routes of the application itself.
+func (app *App) openAPI() ([]byte, error) {
+	doc := openAPIDocument{
+		OpenAPI:    openAPIVersion,
+		Info:       openAPIInfo{Title: "resterrdemo", Version: "unversioned"},
+		Paths:      make(map[string]map[string]openAPIOperation),
+		Components: openAPIComponents{Schemas: map[string]any{"Problem": problemSchema}},
+	}
+
+	doc.add("/healthz", http.MethodGet, openAPIOperation{
+		OperationID: "get-healthz",
+		Responses:   map[string]openAPIResponse{"200": {Description: "The application is alive."}},
+	})
+	doc.add("/readyz", http.MethodGet, openAPIOperation{
+		OperationID: "get-readyz",
+		Responses: map[string]openAPIResponse{
+			"200": {Description: "The application is ready to serve requests."},
+			"503": {Description: "The application or one of its dependencies is not ready."},
+		},
+	})
+	doc.add("/metrics", http.MethodGet, openAPIOperation{
+		OperationID: "get-metrics",
+		Responses:   map[string]openAPIResponse{"200": {Description: "The metrics, in the Prometheus text format."}},
+	})
+	doc.add("/openapi.json", http.MethodGet, openAPIOperation{
+		OperationID: "get-openapi",
+		Responses:   map[string]openAPIResponse{"200": {Description: "This document."}},
+	})
+
+	for _, res := range app.resources {
+		doc.addResource("", res, false)
+	}
+
+	for _, v := range app.versions {
+		deprecated := !v.Deprecation.IsZero()
+		for _, res := range v.Resources {
+			doc.addResource("/"+v.Name, res, deprecated)
+			if v.Default {
+				doc.addResource("", res, deprecated)
+			}
+		}
+
+		// The latest version describes the API.
+		doc.Info.Version = v.Name
+	}
+	return json.Marshal(doc)
+}
+
+// add adds the operation of the method to the path.
+func (doc *openAPIDocument) add(p, method string, op openAPIOperation) {
+	if doc.Paths[p] == nil {
+		doc.Paths[p] = make(map[string]openAPIOperation)
+	}
+	doc.Paths[p][strings.ToLower(method)] = op
+}
+
+// addResource adds the routes of the resource, served under the prefix.
+func (doc *openAPIDocument) addResource(prefix string, res Resource, deprecated bool) {
+	for _, route := range res.Routes {
+		p, params := openAPIPath(prefix + "/" + res.Name + route.Path)
+
+		status := successStatus(route.Method)
+		responses := map[string]openAPIResponse{
+			strconv.Itoa(status): {Description: http.StatusText(status)},
+		}
+		for code, r := range problemResponses(routeProblems(res.ErrorHandler, route)) {
+			responses[code] = r
+		}
+
+		op := openAPIOperation{
+			OperationID: operationID(route.Method, p),
+			Tags:        []string{res.Name},
+			Deprecated:  deprecated,
+			Parameters:  params,
+			Responses:   responses,
+		}
+
+		switch route.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			op.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content:  map[string]openAPIMediaType{"application/json": {Schema: map[string]any{"type": "object"}}},
+			}
+		}
+		doc.add(p, route.Method, op)
+	}
+}
+
+// routeProblems returns the problems of the error responses of the route: the problems its errors translate into,
+// or every problem of the error handler when the route lists no errors, followed by the internal server error.
+func routeProblems(errHandler errorHandler, route Route) []problem.Problem {
+	l, ok := errHandler.(problemLister)
+	if !ok {
+		return []problem.Problem{problem.Internal()}
+	}
+
+	if len(route.Errors) == 0 {
+		return append(l.Problems(), problem.Internal())
+	}
+
+	problems := make([]problem.Problem, 0, len(route.Errors)+1)
+	for _, err := range route.Errors {
+		problems = appendProblem(problems, l.Translate(context.Background(), err))
+	}
+	return appendProblem(problems, problem.Internal())
+}
+
+// appendProblem appends the problem to the problems, unless they already contain it,
+// as errors translating into the same problem describe the same response.
+func appendProblem(problems []problem.Problem, p problem.Problem) []problem.Problem {
+	if slices.ContainsFunc(problems, func(q problem.Problem) bool { return reflect.DeepEqual(p, q) }) {
+		return problems
+	}
+	return append(problems, p)
+}
+
+// problemResponses groups the problems by status into the error responses of an operation,
+// the problems being the examples of the response of their status.
+func problemResponses(problems []problem.Problem) map[string]openAPIResponse {
+	responses := make(map[string]openAPIResponse)
+
+	for _, p := range problems {
+		code := strconv.Itoa(p.Status)
+
+		r, ok := responses[code]
+		if !ok {
+			r = openAPIResponse{
+				Content: map[string]openAPIMediaType{
+					problem.ContentType: {
+						Schema:   map[string]any{"$ref": problemSchemaRef},
+						Examples: make(map[string]openAPIExample),
+					},
+				},
+			}
+		}
+
+		if r.Description != "" {
+			r.Description += " or "
+		}
+		r.Description += p.Title
+
+		examples := r.Content[problem.ContentType].Examples
+		name := exampleName(p)
+		for i := 2; ; i++ {
+			if _, taken := examples[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s-%d", exampleName(p), i)
+		}
+		examples[name] = openAPIExample{Summary: p.Title, Value: p}
+
+		responses[code] = r
+	}
+	return responses
+}
+
+// exampleName names the example of a problem after its type, or its title for the blank type.
+func exampleName(p problem.Problem) string {
+	if p.Type != problem.BlankType {
+		return path.Base(p.Type)
+	}
+	return strings.ReplaceAll(strings.ToLower(p.Title), " ", "-")
+}
+
+// openAPIPath returns the OpenAPI path of a route path, along with the parameters of its wildcards.
+// Wildcards matching the remainder of the path ({name...}) become plain parameters,
+// and the end of path anchor ({$}) is dropped.
+func openAPIPath(p string) (string, []openAPIParameter) {
+	var params []openAPIParameter
+
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+			continue
+		}
+
+		name := strings.TrimSuffix(s[1:len(s)-1], "...")
+		if name == "$" {
+			segments[i] = ""
+			continue
+		}
+
+		segments[i] = "{" + name + "}"
+		params = append(params, openAPIParameter{Name: name, In: "path", Required: true, Schema: map[string]any{"type": "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID identifies the operation of the method on the path, such as get-v2-foo-id.
+func operationID(method, p string) string {
+	id := strings.ToLower(method)
+	for _, s := range strings.Split(p, "/") {
+		if s = strings.Trim(s, "{}"); s != "" {
+			id += "-" + s
+		}
+	}
+	return id
+}
+
+// successStatus is the status of the successful responses of the routes, by method.
+func successStatus(method string) int {
+	switch method {
+	case http.MethodPost:
+		return http.StatusCreated
+	case http.MethodDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// serveOpenAPI serves the OpenAPI document.
+func serveOpenAPI(doc []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	}
+}
@@ -0,0 +1,158 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// joinedDetail is the detail of the problem reporting several joined errors.
+const joinedDetail = "multiple problems occurred"
+
+// joinType is the type of the errors returned by errors.Join, the only errors whose unwrapped errors are reported
+// as joined errors. The other errors unwrapping into several errors, such as the ones formatted by fmt.Errorf
+// with several %w verbs, wrap the causes of a single failure, translated by the first matching entry of the error map.
+var joinType = reflect.TypeOf(errors.Join(errors.New("")))
+
+// StatusPolicy chooses the status of a response reporting several problems,
+// from the statuses of the problems, in the order they were joined.
+type StatusPolicy func(statuses []int) int
+
+// HighestStatus is a status policy choosing the highest status,
+// so that server errors take precedence over client errors.
+func HighestStatus(statuses []int) int {
+	var highest int
+	for _, s := range statuses {
+		highest = max(highest, s)
+	}
+	return highest
+}
+
+// FirstStatus is a status policy choosing the status of the first joined problem.
+func FirstStatus(statuses []int) int {
+	if len(statuses) == 0 {
+		return 0
+	}
+	return statuses[0]
+}
+
+// UniformStatus returns a status policy choosing the status shared by all the problems,
+// or the given fallback status when they differ.
+func UniformStatus(fallback int) StatusPolicy {
+	return func(statuses []int) int {
+		for _, s := range statuses {
+			if s != statuses[0] {
+				return fallback
+			}
+		}
+		return FirstStatus(statuses)
+	}
+}
+
+// WithJoinedErrors is an option to report all the errors joined in the chain of a handled error,
+// such as the errors of a batch aggregated with errors.Join, in an "errors" array of problems.
+// The joined errors are the errors unwrapped from the first error of the chain returned by errors.Join;
+// errors wrapping several errors otherwise, such as with several %w verbs, are not reported this way.
+// They are only reported this way when at least two of them are translated into a problem;
+// the ones that are not are reported as internal server errors without details.
+// The status of the response is chosen by the policy.
+func WithJoinedErrors(policy StatusPolicy) Option {
+	return func(h *Handler) {
+		h.policy = policy
+	}
+}
+
+// joinedError is a joined error, along with what translated it and its problem.
+type joinedError struct {
+	err     error
+	source  string
+	problem Problem
+}
+
+// joined returns the problem reporting all the joined errors of the chain of err,
+// along with the joined errors.
+func (h *Handler) joined(ctx context.Context, err error) (Problem, []joinedError, bool) {
+	if h.policy == nil {
+		return Problem{}, nil, false
+	}
+
+	errs := joinedErrors(err)
+	if len(errs) < 2 {
+		return Problem{}, nil, false
+	}
+
+	var (
+		problems = make([]Problem, 0, len(errs))
+		statuses = make([]int, 0, len(errs))
+		joined   = make([]joinedError, 0, len(errs))
+		mapped   int
+	)
+
+	for _, e := range errs {
+		p, source, ok := h.translate(ctx, e)
+		if ok {
+			mapped++
+		} else {
+			p, source = internalProblem, "unmapped"
+		}
+		problems = append(problems, p)
+		statuses = append(statuses, p.Status)
+		joined = append(joined, joinedError{err: e, source: source, problem: p})
+	}
+
+	if mapped < 2 {
+		return Problem{}, nil, false
+	}
+
+	p := Problem{
+		Status:     h.policy(statuses),
+		Detail:     joinedDetail,
+		Extensions: map[string]any{"errors": problems},
+	}.withDefaults()
+
+	if err := p.validate(); err != nil {
+		h.logger.ErrorContext(ctx, "Ignoring invalid joined errors status.", slog.Int("status", p.Status), slog.String("error", err.Error()))
+		p.Status = http.StatusInternalServerError
+		p.Title = http.StatusText(http.StatusInternalServerError)
+	}
+	return p, joined, true
+}
+
+// recordJoined records each joined error on the span of the request, if any, along with what translated it
+// and the status of its problem. The span is attributed the first joined error with the status of the response.
+func recordJoined(ctx context.Context, errs []joinedError, status int) {
+	for _, e := range errs {
+		translation.Record(ctx, e.err, e.source, e.problem)
+	}
+
+	for _, e := range errs {
+		if e.problem.Status == status {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("error.type", e.source))
+			return
+		}
+	}
+}
+
+// joinedErrors returns the errors unwrapped from the first error of the chain returned by errors.Join, if any.
+// The chain is not walked past an error wrapping several errors otherwise.
+func joinedErrors(err error) []error {
+	for err != nil {
+		switch x := err.(type) {
+		case interface{ Unwrap() []error }:
+			if reflect.TypeOf(err) != joinType {
+				return nil
+			}
+			return x.Unwrap()
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusPolicies(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policy   StatusPolicy
+		given    []int
+		expected int
+	}{
+		{
+			name:     "highest status",
+			policy:   HighestStatus,
+			given:    []int{http.StatusNotFound, http.StatusServiceUnavailable, http.StatusConflict},
+			expected: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "first status",
+			policy:   FirstStatus,
+			given:    []int{http.StatusNotFound, http.StatusServiceUnavailable},
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "first status without statuses",
+			policy:   FirstStatus,
+			expected: 0,
+		},
+		{
+			name:     "uniform status when all statuses are equal",
+			policy:   UniformStatus(http.StatusBadRequest),
+			given:    []int{http.StatusUnprocessableEntity, http.StatusUnprocessableEntity},
+			expected: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "uniform status falls back when statuses differ",
+			policy:   UniformStatus(http.StatusBadRequest),
+			given:    []int{http.StatusUnprocessableEntity, http.StatusConflict},
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.policy(tc.given))
+		})
+	}
+}
+
+func TestHandler_Handle_JoinedErrors(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+	errBar := errors.New("bar err")
+
+	errorMap := Map{
+		{Err: errFoo, Value: Problem{Status: http.StatusNotFound, Detail: "foo"}},
+		{Err: errBar, Value: Problem{Status: http.StatusConflict, Detail: "bar"}},
+	}
+
+	testCases := []struct {
+		name     string
+		policy   StatusPolicy
+		given    error
+		expected string
+	}{
+		{
+			name:   "joined mapped errors",
+			policy: HighestStatus,
+			given:  fmt.Errorf("could not process batch: %w", errors.Join(errFoo, errBar)),
+			expected: `{
+				"type": "about:blank",
+				"status": 409,
+				"title": "Conflict",
+				"detail": "multiple problems occurred",
+				"instance": "/foo",
+				"errors": [
+					{"type": "about:blank", "status": 404, "title": "Not Found", "detail": "foo"},
+					{"type": "about:blank", "status": 409, "title": "Conflict", "detail": "bar"}
+				]
+			}`,
+		},
+		{
+			name:   "unmapped joined errors are reported without details",
+			policy: FirstStatus,
+			given:  errors.Join(errFoo, assert.AnError, errBar),
+			expected: `{
+				"type": "about:blank",
+				"status": 404,
+				"title": "Not Found",
+				"detail": "multiple problems occurred",
+				"instance": "/foo",
+				"errors": [
+					{"type": "about:blank", "status": 404, "title": "Not Found", "detail": "foo"},
+					{"type": "about:blank", "status": 500, "title": "Internal Server Error", "detail": "something went wrong"},
+					{"type": "about:blank", "status": 409, "title": "Conflict", "detail": "bar"}
+				]
+			}`,
+		},
+		{
+			name:   "single mapped joined error",
+			policy: HighestStatus,
+			given:  errors.Join(assert.AnError, errBar),
+			expected: `{
+				"type": "about:blank",
+				"status": 409,
+				"title": "Conflict",
+				"detail": "bar",
+				"instance": "/foo"
+			}`,
+		},
+		{
+			name:   "invalid policy status",
+			policy: func([]int) int { return http.StatusOK },
+			given:  errors.Join(errFoo, errBar),
+			expected: `{
+				"type": "about:blank",
+				"status": 500,
+				"title": "Internal Server Error",
+				"detail": "multiple problems occurred",
+				"instance": "/foo",
+				"errors": [
+					{"type": "about:blank", "status": 404, "title": "Not Found", "detail": "foo"},
+					{"type": "about:blank", "status": 409, "title": "Conflict", "detail": "bar"}
+				]
+			}`,
+		},
+		{
+			name:   "errors wrapped with several verbs resolve to the first declared entry",
+			policy: HighestStatus,
+			given:  fmt.Errorf("could not process foo (%w): %w", errBar, errFoo),
+			expected: `{
+				"type": "about:blank",
+				"status": 404,
+				"title": "Not Found",
+				"detail": "foo",
+				"instance": "/foo"
+			}`,
+		},
+		{
+			name:   "joined errors wrapped with several verbs are not reported together",
+			policy: HighestStatus,
+			given:  fmt.Errorf("could not process batch (%w): %w", assert.AnError, errors.Join(errBar, errFoo)),
+			expected: `{
+				"type": "about:blank",
+				"status": 404,
+				"title": "Not Found",
+				"detail": "foo",
+				"instance": "/foo"
+			}`,
+		},
+		{
+			name:  "joined errors without the option",
+			given: errors.Join(errFoo, errBar),
+			expected: `{
+				"type": "about:blank",
+				"status": 404,
+				"title": "Not Found",
+				"detail": "foo",
+				"instance": "/foo"
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []Option
+			if tc.policy != nil {
+				opts = append(opts, WithJoinedErrors(tc.policy))
+			}
+
+			handler, err := NewHandler(noopLogger, errorMap, opts...)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			handler.Handle(NewContext(context.Background(), "/foo"), rec, tc.given)
+
+			assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,509 @@
+// problem package translates errors into RFC 9457 problem details responses.
+// Errors are looked up in an ordered error map provided at initialization, and unmapped
+// errors are sent to the client as internal server errors without details.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/alesr/resterrdemo/app/errmap"
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// ContentType is the media type of problem details responses.
+	ContentType = problemtype.ContentType
+
+	// BlankType is the problem type used when a problem has no additional semantics beyond the status code.
+	BlankType = "about:blank"
+
+	// requestIDMember is the extension member carrying the ID of the request the problem was written for.
+	requestIDMember = "request-id"
+)
+
+// internalProblem is the problem written for the errors that are not mapped, without details.
+var internalProblem = Problem{
+	Type:   BlankType,
+	Status: http.StatusInternalServerError,
+	Title:  http.StatusText(http.StatusInternalServerError),
+	Detail: "something went wrong",
+}
+
+// Internal returns the problem written for the errors that are not mapped.
+func Internal() Problem {
+	return internalProblem
+}
+
+// kindStatus is the status code used for the domain errors
+// that are public but not in the error map, based on their kind.
+var kindStatus = map[domainerr.Kind]int{
+	domainerr.KindNotFound:    http.StatusNotFound,
+	domainerr.KindUnavailable: http.StatusServiceUnavailable,
+	domainerr.KindInvalid:     http.StatusUnprocessableEntity,
+	domainerr.KindConflict:    http.StatusConflict,
+}
+
+// Problem represents an RFC 9457 problem details object.
+// Extensions are marshaled as top level members next to the standard ones.
+type Problem problemtype.Details
+
+// Error implements the error interface.
+func (p Problem) Error() string {
+	return fmt.Sprintf("type: '%s', status: '%d', title: '%s', detail: '%s'", p.Type, p.Status, p.Title, p.Detail)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(problemtype.Details(p))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Members other than the standard ones are collected as extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*problemtype.Details)(p))
+}
+
+// validate checks that the problem can be sent to a client.
+func (p Problem) validate() error {
+	if p.Status < http.StatusBadRequest || p.Status > 599 {
+		return fmt.Errorf("status '%d' is not an error status", p.Status)
+	}
+
+	if p.Type != BlankType {
+		u, err := url.Parse(p.Type)
+		if err != nil || !u.IsAbs() {
+			return fmt.Errorf("type '%s' is not an absolute URI", p.Type)
+		}
+	}
+
+	if p.Title == "" {
+		return errors.New("title is required")
+	}
+	return nil
+}
+
+// withDefaults fills the members that can be derived from the status code.
+func (p Problem) withDefaults() Problem {
+	if p.Type == "" {
+		p.Type = BlankType
+	}
+
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	return p
+}
+
+// Entry maps an error to the problem sent to the client
+// when the error is found in the chain of a handled error.
+type Entry = errmap.Entry[Problem]
+
+// Map is an ordered error map. When the chain of a handled error matches several entries,
+// such as errors wrapping more than one error or joined errors, the first entry wins.
+// More specific errors should therefore be declared before the errors they may be found with.
+type Map = errmap.Map[Problem]
+
+// translation describes the problems to the error maps shared with the other transports,
+// which record the handled errors on the spans of the requests along with the status of the response.
+// Only server errors mark the span as failed, client errors being expected.
+var translation = errmap.Translation[Problem]{
+	Name:     "problem",
+	Unmapped: func(error) Problem { return internalProblem },
+	Attributes: func(p Problem) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.Int("http.response.status_code", p.Status)}
+	},
+	Failed: func(p Problem) bool { return p.Status >= http.StatusInternalServerError },
+}
+
+// forRequest returns a copy of the problem identifying the request it is written for,
+// with the instance and request ID stored in the context.
+func (p Problem) forRequest(ctx context.Context) Problem {
+	p.Instance = InstanceFromContext(ctx)
+
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return p
+	}
+
+	// The extensions of the mapped problems are shared between requests, and must not be modified.
+	extensions := make(map[string]any, len(p.Extensions)+1)
+	for k, v := range p.Extensions {
+		extensions[k] = v
+	}
+	extensions[requestIDMember] = id
+
+	p.Extensions = extensions
+	return p
+}
+
+// Matcher translates errors that cannot be mapped by identity in the error map,
+// such as errors whose type carries data.
+type Matcher interface {
+	// Match returns the problem for the error, and whether the error matched.
+	Match(err error) (Problem, bool)
+}
+
+// MatcherFunc is an adapter to allow the use of ordinary functions as matchers.
+type MatcherFunc func(err error) (Problem, bool)
+
+// Match implements the Matcher interface.
+func (f MatcherFunc) Match(err error) (Problem, bool) { return f(err) }
+
+// As returns a matcher for the errors of type E in the error tree.
+// The matched error is passed to fn, which builds the problem from the data the error carries,
+// or declines it. In that case, the next error of type E in the tree is tried.
+func As[E error](fn func(e E) (Problem, bool)) Matcher {
+	return MatcherFunc(func(err error) (Problem, bool) {
+		var p Problem
+
+		found := walk(err, func(err error) bool {
+			e, ok := err.(E)
+			if !ok {
+				return false
+			}
+
+			var matched bool
+			p, matched = fn(e)
+			return matched
+		})
+		return p, found
+	})
+}
+
+type instanceCtxKey struct{}
+
+// NewContext returns a copy of the context carrying the instance reported on problems
+// written for the request (usually the request URI).
+func NewContext(ctx context.Context, instance string) context.Context {
+	return context.WithValue(ctx, instanceCtxKey{}, instance)
+}
+
+// InstanceFromContext returns the instance stored in the context, if any.
+func InstanceFromContext(ctx context.Context) string {
+	instance, _ := ctx.Value(instanceCtxKey{}).(string)
+	return instance
+}
+
+// Middleware stores the request path in the request context, so that the problems
+// written while handling the request identify the occurrence of the problem.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), r.URL.Path)))
+	})
+}
+
+// Handler handles standard errors by logging them and looking for an equivalent problem in the error map.
+// Errors that are not mapped result in internal server errors.
+type Handler struct {
+	logger   *slog.Logger
+	errorMap Map
+	matchers []Matcher
+	defaults map[string]struct{}
+	policy   StatusPolicy
+
+	registerer  prometheus.Registerer
+	errorsTotal *prometheus.CounterVec
+}
+
+// Option applies custom behavior to the handler.
+type Option func(h *Handler)
+
+// WithMatchers is an option to translate the errors that cannot be mapped by identity.
+// Matchers are tried in order, before the error map: they match errors carrying data,
+// which are more specific than the sentinels they may wrap.
+func WithMatchers(matchers ...Matcher) Option {
+	return func(h *Handler) {
+		h.matchers = append(h.matchers, matchers...)
+	}
+}
+
+// WithKindDefaults is an option to translate the public domain errors of the registry
+// that are not in the error map into a problem derived from their kind.
+// Hidden errors are never translated this way.
+func WithKindDefaults(registry domainerr.Registry) Option {
+	return func(h *Handler) {
+		for _, s := range registry {
+			var e *domainerr.Error
+			if s.Visibility != domainerr.Public || !errors.As(s.Err, &e) {
+				continue
+			}
+
+			if _, ok := kindStatus[e.Kind]; ok {
+				h.defaults[e.Code] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithMetrics is an option to count the handled errors of the resource in the registerer,
+// labelled by the sentinel that translated them (see translate), or "unmapped" for the internal server errors.
+// Each of the joined errors is counted. The handlers sharing a registerer must count distinct resources,
+// such as the versions of a resource translating its errors with error maps of their own.
+func WithMetrics(registerer prometheus.Registerer, resource string) Option {
+	return func(h *Handler) {
+		h.registerer = registerer
+		h.errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "problem_errors_total",
+			Help:        "Errors handled by the resource error handlers, by what translated them.",
+			ConstLabels: prometheus.Labels{"resource": resource},
+		}, []string{"error"})
+	}
+}
+
+// NewHandler returns a problem details error handler.
+// The problems in the error map are completed with the defaults derived
+// from their status code, and validated. Entries that can never be used,
+// because an earlier entry matches the same errors, are logged as warnings.
+func NewHandler(logger *slog.Logger, errorMap Map, opts ...Option) (*Handler, error) {
+	h := Handler{
+		logger:   logger.WithGroup("problem-handler"),
+		errorMap: make(Map, 0, len(errorMap)),
+		defaults: make(map[string]struct{}),
+	}
+
+	for _, o := range opts {
+		o(&h)
+	}
+
+	for _, e := range errorMap {
+		if e.Err == nil {
+			return nil, errors.New("error map entry has no error")
+		}
+
+		p := e.Value.withDefaults()
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid problem for error '%v': %w", e.Err, err)
+		}
+
+		if prev, ok := h.errorMap.Find(e.Err); ok {
+			h.logger.Warn(
+				"Error map entry is shadowed by an earlier entry.",
+				slog.String("error", e.Err.Error()),
+				slog.String("shadowed-by", prev.Err.Error()),
+			)
+		}
+		h.errorMap = append(h.errorMap, Entry{Err: e.Err, Value: p})
+	}
+
+	if h.registerer != nil {
+		if err := h.registerer.Register(h.errorsTotal); err != nil {
+			return nil, fmt.Errorf("could not register error metrics: %w", err)
+		}
+	}
+	return &h, nil
+}
+
+// Problems returns the problems of the error map, in order, completed with their defaults.
+// Together with Internal, they describe the error responses the handler writes.
+func (h *Handler) Problems() []Problem {
+	problems := make([]Problem, 0, len(h.errorMap))
+	for _, e := range h.errorMap {
+		problems = append(problems, e.Value)
+	}
+	return problems
+}
+
+// IsMapped reports whether the error is translated into a problem,
+// either by a matcher, from the error map or from its kind.
+func (h *Handler) IsMapped(err error) bool {
+	_, _, ok := h.translate(context.Background(), err)
+	return ok
+}
+
+// Handle logs the original error and writes the problem it translates into as JSON.
+// The translation is looked up, in order, from a problem in the chain, the matchers,
+// the first matching entry of the error map and the kind of the domain errors.
+// If none of them translates the error, it writes a problem indicating an internal server error.
+func (h *Handler) Handle(ctx context.Context, w http.ResponseWriter, err error) {
+	if p, errs, ok := h.joined(ctx, err); ok {
+		sources := make([]string, 0, len(errs))
+		for _, e := range errs {
+			sources = append(sources, e.source)
+			h.count(e.source)
+		}
+
+		h.logger.ErrorContext(ctx, "Handling joined errors.", slog.String("error", err.Error()), slog.Any("mapped-by", sources))
+		recordJoined(ctx, errs, p.Status)
+		h.write(ctx, w, p)
+		return
+	}
+
+	p, source, ok := h.translate(ctx, err)
+	if !ok {
+		h.logger.ErrorContext(ctx, "Handling unmapped error.", slog.String("source-error", err.Error()))
+		h.count("unmapped")
+		translation.Record(ctx, err, "unmapped", internalProblem)
+		h.write(ctx, w, internalProblem)
+		return
+	}
+
+	h.logger.ErrorContext(ctx, "Handling mapped error.", slog.String("error", err.Error()), slog.String("mapped-by", source))
+	h.count(source)
+	translation.Record(ctx, err, source, p)
+	h.write(ctx, w, p)
+}
+
+// count counts the handled error, if the handler has metrics.
+func (h *Handler) count(source string) {
+	if h.errorsTotal != nil {
+		h.errorsTotal.WithLabelValues(source).Inc()
+	}
+}
+
+// Translate returns the problem the error translates into, as Handle would write it
+// except for the request specific members, without logging or writing anything.
+// Errors that are not mapped translate into the internal server error problem.
+func (h *Handler) Translate(ctx context.Context, err error) Problem {
+	if p, _, ok := h.joined(ctx, err); ok {
+		return p
+	}
+
+	if p, _, ok := h.translate(ctx, err); ok {
+		return p
+	}
+	return internalProblem
+}
+
+// translate returns the problem the error translates into, and the sentinel that translated it:
+// the matching entry of the error map or the domain error translated from its kind, named by
+// errmap.SentinelName. Problems in the chain and the problems of the matchers are derived from
+// the data of an error rather than from a sentinel, so they are named after the first domain error
+// of the chain, or "problem" and "matcher" when there is none.
+func (h *Handler) translate(ctx context.Context, err error) (Problem, string, bool) {
+	var p Problem
+	if errors.As(err, &p) {
+		return p.withDefaults(), domainCode(err, "problem"), true
+	}
+
+	if p, ok := h.match(ctx, err); ok {
+		return p, domainCode(err, "matcher"), true
+	}
+
+	if e, ok := h.errorMap.Find(err); ok {
+		return e.Value, errmap.SentinelName(e.Err), true
+	}
+
+	if p, code, ok := h.kindDefault(err); ok {
+		return p, code, true
+	}
+	return Problem{}, "", false
+}
+
+// match returns the problem of the first matcher matching the error.
+// Problems built by matchers are completed with their defaults, and are only used if valid.
+func (h *Handler) match(ctx context.Context, err error) (Problem, bool) {
+	for _, m := range h.matchers {
+		p, ok := m.Match(err)
+		if !ok {
+			continue
+		}
+
+		p = p.withDefaults()
+		if verr := p.validate(); verr != nil {
+			h.logger.ErrorContext(ctx, "Ignoring invalid matched problem.", slog.String("source-error", err.Error()), slog.String("error", verr.Error()))
+			continue
+		}
+		return p, true
+	}
+	return Problem{}, false
+}
+
+// domainCode returns the code of the first domain error in the tree of err, or the fallback if there is none.
+func domainCode(err error, fallback string) string {
+	code := fallback
+	walk(err, func(err error) bool {
+		e, ok := err.(*domainerr.Error)
+		if ok {
+			code = e.Code
+		}
+		return ok
+	})
+	return code
+}
+
+// kindDefault looks for the first domain error in the tree of err that is eligible for the
+// kind based translation, and returns its problem and code.
+func (h *Handler) kindDefault(err error) (Problem, string, bool) {
+	var (
+		p    Problem
+		code string
+	)
+
+	found := walk(err, func(err error) bool {
+		e, ok := err.(*domainerr.Error)
+		if !ok {
+			return false
+		}
+
+		if _, ok := h.defaults[e.Code]; !ok {
+			return false
+		}
+
+		p = Problem{
+			Status: kindStatus[e.Kind],
+			Detail: e.Message,
+			Extensions: map[string]any{
+				"code":      e.Code,
+				"retryable": e.Retryable,
+			},
+		}.withDefaults()
+		code = e.Code
+		return true
+	})
+	return p, code, found
+}
+
+// walk calls fn for every error in the tree of err, depth first, until fn returns true.
+// It reports whether fn returned true.
+func walk(err error, fn func(err error) bool) bool {
+	if err == nil {
+		return false
+	}
+
+	if fn(err) {
+		return true
+	}
+
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return walk(x.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, e := range x.Unwrap() {
+			if walk(e, fn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *Handler) write(ctx context.Context, w http.ResponseWriter, p Problem) {
+	p = p.forRequest(ctx)
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to marshal problem.", slog.String("source-error", p.Error()), slog.String("error", err.Error()))
+
+		p = internalProblem.forRequest(ctx)
+
+		// The internal problem only has the request ID as extension and always marshals.
+		payload, _ = json.Marshal(p)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+
+	if _, err := w.Write(payload); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to write problem.", slog.String("source-error", p.Error()), slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,817 @@
+package problem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	testCases := []struct {
+		name          string
+		given         Map
+		expectedError string
+		expectedEntry Problem
+	}{
+		{
+			name: "defaults are derived from status",
+			given: Map{
+				{Err: errFoo, Value: Problem{Status: http.StatusTeapot, Detail: "foo"}},
+			},
+			expectedEntry: Problem{
+				Type:   BlankType,
+				Status: http.StatusTeapot,
+				Title:  "I'm a teapot",
+				Detail: "foo",
+			},
+		},
+		{
+			name: "declared members are kept",
+			given: Map{
+				{Err: errFoo, Value: Problem{Type: "https://example.com/foo", Status: http.StatusConflict, Title: "Foo", Detail: "foo"}},
+			},
+			expectedEntry: Problem{
+				Type:   "https://example.com/foo",
+				Status: http.StatusConflict,
+				Title:  "Foo",
+				Detail: "foo",
+			},
+		},
+		{
+			name: "non error status",
+			given: Map{
+				{Err: errFoo, Value: Problem{Status: http.StatusOK}},
+			},
+			expectedError: "status '200' is not an error status",
+		},
+		{
+			name: "relative type",
+			given: Map{
+				{Err: errFoo, Value: Problem{Type: "problems/foo", Status: http.StatusConflict}},
+			},
+			expectedError: "type 'problems/foo' is not an absolute URI",
+		},
+		{
+			name: "missing title for non standard status",
+			given: Map{
+				{Err: errFoo, Value: Problem{Status: 499}},
+			},
+			expectedError: "title is required",
+		},
+		{
+			name: "entry without error",
+			given: Map{
+				{Value: Problem{Status: http.StatusTeapot}},
+			},
+			expectedError: "error map entry has no error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewHandler(noopLogger, tc.given)
+
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, got.logger)
+			entry, ok := got.errorMap.Lookup(errFoo)
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedEntry, entry)
+		})
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	handler, err := NewHandler(noopLogger, Map{
+		{
+			Err: errFoo,
+			Value: Problem{
+				Type:       "https://example.com/foo",
+				Status:     http.StatusTeapot,
+				Title:      "Foo",
+				Detail:     "foo went wrong",
+				Extensions: map[string]any{"retryable": true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		given    error
+		expected string
+	}{
+		{
+			name:  "mapped error",
+			given: fmt.Errorf("wrapped: %w", errFoo),
+			expected: `{
+				"type": "https://example.com/foo",
+				"status": 418,
+				"title": "Foo",
+				"detail": "foo went wrong",
+				"instance": "/foo/1",
+				"retryable": true
+			}`,
+		},
+		{
+			name:  "unmapped error",
+			given: assert.AnError,
+			expected: `{
+				"type": "about:blank",
+				"status": 500,
+				"title": "Internal Server Error",
+				"detail": "something went wrong",
+				"instance": "/foo/1"
+			}`,
+		},
+		{
+			name:  "problem error",
+			given: fmt.Errorf("wrapped: %w", Problem{Status: http.StatusConflict, Detail: "conflict"}),
+			expected: `{
+				"type": "about:blank",
+				"status": 409,
+				"title": "Conflict",
+				"detail": "conflict",
+				"instance": "/foo/1"
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := NewContext(context.TODO(), "/foo/1")
+
+			w := httptest.NewRecorder()
+			handler.Handle(ctx, w, tc.given)
+
+			var expected Problem
+			require.NoError(t, json.Unmarshal([]byte(tc.expected), &expected))
+
+			assert.Equal(t, expected.Status, w.Result().StatusCode)
+			assert.Equal(t, ContentType, w.Result().Header.Get("Content-Type"))
+			assert.JSONEq(t, tc.expected, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_Handle_RequestID(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errFoo, Value: Problem{Status: http.StatusTeapot, Extensions: map[string]any{"retryable": true}}},
+	})
+	require.NoError(t, err)
+
+	ctx := requestid.NewContext(NewContext(context.TODO(), "/foo/1"), "42")
+
+	w := httptest.NewRecorder()
+	handler.Handle(ctx, w, errFoo)
+
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"status": 418,
+		"title": "I'm a teapot",
+		"instance": "/foo/1",
+		"retryable": true,
+		"request-id": "42"
+	}`, w.Body.String())
+
+	// The request ID must not leak into the problems written for other requests.
+	w = httptest.NewRecorder()
+	handler.Handle(context.TODO(), w, errFoo)
+
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"status": 418,
+		"title": "I'm a teapot",
+		"retryable": true
+	}`, w.Body.String())
+}
+
+func TestHandler_Handle_KindDefaults(t *testing.T) {
+	t.Parallel()
+
+	errPublic := domainerr.New(domainerr.KindNotFound, "foo.not_found", "foo not found")
+	errRetryable := domainerr.NewRetryable(domainerr.KindUnavailable, "foo.unavailable", "foo unavailable")
+	errHidden := domainerr.New(domainerr.KindConflict, "foo.duplicate", "foo duplicate")
+	errUnknown := domainerr.New(domainerr.KindUnknown, "foo.unknown", "foo unknown")
+	errMapped := domainerr.New(domainerr.KindInvalid, "foo.invalid", "foo invalid")
+
+	registry := domainerr.Registry{
+		{Err: errPublic, Visibility: domainerr.Public},
+		{Err: errRetryable, Visibility: domainerr.Public},
+		{Err: errHidden, Visibility: domainerr.Hidden},
+		{Err: errUnknown, Visibility: domainerr.Public},
+		{Err: errMapped, Visibility: domainerr.Public},
+	}
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errMapped, Value: Problem{Status: http.StatusBadRequest, Detail: "mapped"}},
+	}, WithKindDefaults(registry))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		given    error
+		expected string
+	}{
+		{
+			name:  "public error translated from kind",
+			given: fmt.Errorf("wrapped: %w", errPublic.With("id", "42")),
+			expected: `{
+				"type": "about:blank",
+				"status": 404,
+				"title": "Not Found",
+				"detail": "foo not found",
+				"code": "foo.not_found",
+				"retryable": false
+			}`,
+		},
+		{
+			name:  "retryable error translated from kind",
+			given: errRetryable,
+			expected: `{
+				"type": "about:blank",
+				"status": 503,
+				"title": "Service Unavailable",
+				"detail": "foo unavailable",
+				"code": "foo.unavailable",
+				"retryable": true
+			}`,
+		},
+		{
+			name:  "hidden error is not translated",
+			given: errHidden,
+			expected: `{
+				"type": "about:blank",
+				"status": 500,
+				"title": "Internal Server Error",
+				"detail": "something went wrong"
+			}`,
+		},
+		{
+			name:  "hidden error is skipped for a public one",
+			given: fmt.Errorf("could not fetch (%w): %w", errHidden, errPublic),
+			expected: `{
+				"type": "about:blank",
+				"status": 404,
+				"title": "Not Found",
+				"detail": "foo not found",
+				"code": "foo.not_found",
+				"retryable": false
+			}`,
+		},
+		{
+			name:  "unknown kind is not translated",
+			given: errUnknown,
+			expected: `{
+				"type": "about:blank",
+				"status": 500,
+				"title": "Internal Server Error",
+				"detail": "something went wrong"
+			}`,
+		},
+		{
+			name:  "error map takes precedence",
+			given: errMapped,
+			expected: `{
+				"type": "about:blank",
+				"status": 400,
+				"title": "Bad Request",
+				"detail": "mapped"
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			handler.Handle(context.TODO(), w, tc.given)
+
+			assert.JSONEq(t, tc.expected, w.Body.String())
+		})
+	}
+
+	assert.True(t, handler.IsMapped(errPublic))
+	assert.True(t, handler.IsMapped(errMapped))
+	assert.False(t, handler.IsMapped(errHidden))
+	assert.False(t, handler.IsMapped(errUnknown))
+}
+
+type fieldError struct {
+	field string
+}
+
+func (e *fieldError) Error() string { return "invalid field " + e.field }
+
+func TestHandler_Handle_Matchers(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errFoo, Value: Problem{Status: http.StatusBadRequest, Detail: "foo"}},
+	}, WithMatchers(
+		As(func(e *fieldError) (Problem, bool) {
+			if e.field == "declined" {
+				return Problem{}, false
+			}
+			return Problem{
+				Status:     http.StatusUnprocessableEntity,
+				Detail:     "invalid field",
+				Extensions: map[string]any{"field": e.field},
+			}, true
+		}),
+		MatcherFunc(func(err error) (Problem, bool) {
+			return Problem{Status: http.StatusOK}, errors.Is(err, assert.AnError)
+		}),
+	))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		given    error
+		expected string
+	}{
+		{
+			name:  "matched error contributes its data",
+			given: fmt.Errorf("wrapped: %w", &fieldError{field: "name"}),
+			expected: `{
+				"type": "about:blank",
+				"status": 422,
+				"title": "Unprocessable Entity",
+				"detail": "invalid field",
+				"field": "name"
+			}`,
+		},
+		{
+			name:  "declined error falls back to the next error of the type",
+			given: errors.Join(&fieldError{field: "declined"}, &fieldError{field: "size"}),
+			expected: `{
+				"type": "about:blank",
+				"status": 422,
+				"title": "Unprocessable Entity",
+				"detail": "invalid field",
+				"field": "size"
+			}`,
+		},
+		{
+			name:  "matchers take precedence over the error map",
+			given: fmt.Errorf("%w: %w", errFoo, &fieldError{field: "name"}),
+			expected: `{
+				"type": "about:blank",
+				"status": 422,
+				"title": "Unprocessable Entity",
+				"detail": "invalid field",
+				"field": "name"
+			}`,
+		},
+		{
+			name:  "declined error falls back to the error map",
+			given: fmt.Errorf("%w: %w", errFoo, &fieldError{field: "declined"}),
+			expected: `{
+				"type": "about:blank",
+				"status": 400,
+				"title": "Bad Request",
+				"detail": "foo"
+			}`,
+		},
+		{
+			name:  "invalid matched problem is ignored",
+			given: assert.AnError,
+			expected: `{
+				"type": "about:blank",
+				"status": 500,
+				"title": "Internal Server Error",
+				"detail": "something went wrong"
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			handler.Handle(context.TODO(), w, tc.given)
+
+			assert.JSONEq(t, tc.expected, w.Body.String())
+		})
+	}
+
+	assert.True(t, handler.IsMapped(&fieldError{field: "name"}))
+	assert.False(t, handler.IsMapped(&fieldError{field: "declined"}))
+}
+
+func TestHandler_Handle_Precedence(t *testing.T) {
+	t.Parallel()
+
+	errSpecific := errors.New("specific err")
+	errGeneric := errors.New("generic err")
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errSpecific, Value: Problem{Status: http.StatusNotFound}},
+		{Err: errGeneric, Value: Problem{Status: http.StatusServiceUnavailable}},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		given          error
+		expectedStatus int
+	}{
+		{
+			name:           "multiple wrapped errors in declaration order",
+			given:          fmt.Errorf("op (%w): %w", errSpecific, errGeneric),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "multiple wrapped errors in reverse order",
+			given:          fmt.Errorf("op (%w): %w", errGeneric, errSpecific),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "generic error wrapping the specific one deeper in the chain",
+			given:          fmt.Errorf("op: %w", errors.Join(errGeneric, fmt.Errorf("cause: %w", errSpecific))),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "joined errors",
+			given:          errors.Join(errGeneric, errSpecific),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "joined errors with a single mapped error",
+			given:          errors.Join(assert.AnError, errGeneric),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The same chain must always be translated into the same problem.
+			for range 20 {
+				rec := httptest.NewRecorder()
+				handler.Handle(context.Background(), rec, tc.given)
+
+				require.Equal(t, tc.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewHandler_ShadowedEntries(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+	errWrappingFoo := fmt.Errorf("bar err: %w", errFoo)
+
+	testCases := []struct {
+		name             string
+		given            Map
+		expectedWarnings int
+	}{
+		{
+			name: "more specific error first",
+			given: Map{
+				{Err: errWrappingFoo, Value: Problem{Status: http.StatusConflict}},
+				{Err: errFoo, Value: Problem{Status: http.StatusTeapot}},
+			},
+		},
+		{
+			name: "more specific error last",
+			given: Map{
+				{Err: errFoo, Value: Problem{Status: http.StatusTeapot}},
+				{Err: errWrappingFoo, Value: Problem{Status: http.StatusConflict}},
+			},
+			expectedWarnings: 1,
+		},
+		{
+			name: "duplicated error",
+			given: Map{
+				{Err: errFoo, Value: Problem{Status: http.StatusTeapot}},
+				{Err: errFoo, Value: Problem{Status: http.StatusConflict}},
+			},
+			expectedWarnings: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			_, err := NewHandler(logger, tc.given)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedWarnings, strings.Count(buf.String(), "Error map entry is shadowed by an earlier entry."))
+		})
+	}
+}
+
+func TestHandler_IsMapped(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errFoo, Value: Problem{Status: http.StatusTeapot}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, handler.IsMapped(errFoo))
+	assert.True(t, handler.IsMapped(fmt.Errorf("wrapped: %w", errFoo)))
+	assert.False(t, handler.IsMapped(assert.AnError))
+}
+
+func TestHandler_Handle_Metrics(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+	errTyped := domainerr.New(domainerr.KindNotFound, "foo.not_found", "foo not found")
+	errKind := domainerr.New(domainerr.KindConflict, "foo.conflict", "foo conflict")
+
+	registry := prometheus.NewRegistry()
+
+	handler, err := NewHandler(
+		noopLogger,
+		Map{
+			{Err: errFoo, Value: Problem{Status: http.StatusTeapot}},
+			{Err: errTyped, Value: Problem{Status: http.StatusNotFound}},
+		},
+		WithMatchers(As(func(e *domainerr.Error) (Problem, bool) {
+			id, ok := e.Metadata["id"]
+			return Problem{Status: http.StatusNotFound, Extensions: map[string]any{"id": id}}, ok
+		})),
+		WithKindDefaults(domainerr.Registry{{Err: errKind, Visibility: domainerr.Public}}),
+		WithJoinedErrors(HighestStatus),
+		WithMetrics(registry, "foo"),
+	)
+	require.NoError(t, err)
+
+	for _, err := range []error{
+		errFoo,
+		fmt.Errorf("wrapped: %w", errFoo),
+		errTyped,
+		errTyped.With("id", "1"),
+		errKind,
+		errors.Join(errFoo, errTyped),
+		Problem{Status: http.StatusConflict},
+		assert.AnError,
+	} {
+		handler.Handle(context.TODO(), httptest.NewRecorder(), err)
+	}
+
+	// The matched errors and each of the joined errors are counted by the sentinel they derive from.
+	expected := `
+# HELP problem_errors_total Errors handled by the resource error handlers, by what translated them.
+# TYPE problem_errors_total counter
+problem_errors_total{error="foo err",resource="foo"} 3
+problem_errors_total{error="foo.conflict",resource="foo"} 1
+problem_errors_total{error="foo.not_found",resource="foo"} 3
+problem_errors_total{error="problem",resource="foo"} 1
+problem_errors_total{error="unmapped",resource="foo"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "problem_errors_total"))
+}
+
+func TestNewHandler_Metrics_DistinctResources(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	_, err := NewHandler(noopLogger, Map{}, WithMetrics(registry, "foo-v1"))
+	require.NoError(t, err)
+
+	_, err = NewHandler(noopLogger, Map{}, WithMetrics(registry, "foo-v2"))
+	require.NoError(t, err)
+
+	_, err = NewHandler(noopLogger, Map{}, WithMetrics(registry, "foo-v1"))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	assert.ErrorAs(t, err, &alreadyRegistered)
+}
+
+func TestHandler_Handle_Tracing(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+	errTyped := domainerr.New(domainerr.KindNotFound, "foo.not_found", "foo not found")
+
+	handler, err := NewHandler(
+		noopLogger,
+		Map{
+			{Err: errFoo, Value: Problem{Status: http.StatusServiceUnavailable}},
+			{Err: errTyped, Value: Problem{Status: http.StatusNotFound}},
+		},
+		WithMatchers(As(func(e *domainerr.Error) (Problem, bool) {
+			_, ok := e.Metadata["id"]
+			return Problem{Status: http.StatusGone}, ok
+		})),
+		WithJoinedErrors(HighestStatus),
+	)
+	require.NoError(t, err)
+
+	type event struct {
+		err       error
+		errorType string
+		status    int
+	}
+
+	testCases := []struct {
+		name               string
+		err                error
+		expectedErrorType  string
+		expectedEvents     []event
+		expectedStatusCode codes.Code
+	}{
+		{
+			name:               "sentinel",
+			err:                fmt.Errorf("wrapped: %w", errFoo),
+			expectedErrorType:  "foo err",
+			expectedEvents:     []event{{err: fmt.Errorf("wrapped: %w", errFoo), errorType: "foo err", status: http.StatusServiceUnavailable}},
+			expectedStatusCode: codes.Error,
+		},
+		{
+			name:               "domain error",
+			err:                errTyped,
+			expectedErrorType:  "foo.not_found",
+			expectedEvents:     []event{{err: errTyped, errorType: "foo.not_found", status: http.StatusNotFound}},
+			expectedStatusCode: codes.Unset,
+		},
+		{
+			name:               "matched domain error",
+			err:                errTyped.With("id", "1"),
+			expectedErrorType:  "foo.not_found",
+			expectedEvents:     []event{{err: errTyped.With("id", "1"), errorType: "foo.not_found", status: http.StatusGone}},
+			expectedStatusCode: codes.Unset,
+		},
+		{
+			name:              "joined errors",
+			err:               errors.Join(errTyped, errFoo),
+			expectedErrorType: "foo err",
+			expectedEvents: []event{
+				{err: errTyped, errorType: "foo.not_found", status: http.StatusNotFound},
+				{err: errFoo, errorType: "foo err", status: http.StatusServiceUnavailable},
+			},
+			expectedStatusCode: codes.Error,
+		},
+		{
+			name:               "unmapped",
+			err:                assert.AnError,
+			expectedErrorType:  "unmapped",
+			expectedEvents:     []event{{err: assert.AnError, errorType: "unmapped", status: http.StatusInternalServerError}},
+			expectedStatusCode: codes.Error,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test").Start(context.TODO(), "request")
+
+			handler.Handle(ctx, httptest.NewRecorder(), tc.err)
+			span.End()
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+
+			assert.Equal(t, tc.expectedStatusCode, spans[0].Status.Code)
+			assert.Contains(t, spans[0].Attributes, attribute.String("error.type", tc.expectedErrorType))
+
+			require.Len(t, spans[0].Events, len(tc.expectedEvents))
+			for i, e := range tc.expectedEvents {
+				assert.Subset(t, spans[0].Events[i].Attributes, []attribute.KeyValue{
+					attribute.String("exception.message", e.err.Error()),
+					attribute.String("error.type", e.errorType),
+					attribute.Int("http.response.status_code", e.status),
+				})
+			}
+		})
+	}
+}
+
+func TestHandler_Translate(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errFoo, Value: Problem{Status: http.StatusTeapot}},
+	})
+	require.NoError(t, err)
+
+	ctx := requestid.NewContext(NewContext(context.TODO(), "/foo/1"), "42")
+
+	assert.Equal(t, Problem{Type: BlankType, Status: http.StatusTeapot, Title: "I'm a teapot"}, handler.Translate(ctx, fmt.Errorf("wrapped: %w", errFoo)))
+	assert.Equal(t, internalProblem, handler.Translate(ctx, assert.AnError))
+}
+
+func TestHandler_Problems(t *testing.T) {
+	t.Parallel()
+
+	handler, err := NewHandler(noopLogger, Map{
+		{Err: errors.New("foo err"), Value: Problem{Status: http.StatusTeapot}},
+		{Err: errors.New("bar err"), Value: Problem{Type: "https://example.com/bar", Status: http.StatusConflict, Title: "Bar", Detail: "bar"}},
+	})
+	require.NoError(t, err)
+
+	expected := []Problem{
+		{Type: BlankType, Status: http.StatusTeapot, Title: "I'm a teapot"},
+		{Type: "https://example.com/bar", Status: http.StatusConflict, Title: "Bar", Detail: "bar"},
+	}
+	assert.Equal(t, expected, handler.Problems())
+	assert.Equal(t, internalProblem, Internal())
+}
+
+func TestProblem_JSON(t *testing.T) {
+	t.Parallel()
+
+	given := Problem{
+		Type:       "https://example.com/foo",
+		Status:     http.StatusConflict,
+		Title:      "Foo",
+		Detail:     "foo went wrong",
+		Instance:   "/foo/1",
+		Extensions: map[string]any{"invalid-params": []any{"name"}},
+	}
+
+	payload, err := json.Marshal(given)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"type": "https://example.com/foo",
+		"status": 409,
+		"title": "Foo",
+		"detail": "foo went wrong",
+		"instance": "/foo/1",
+		"invalid-params": ["name"]
+	}`, string(payload))
+
+	var got Problem
+	require.NoError(t, json.Unmarshal(payload, &got))
+
+	assert.Equal(t, given, got)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var got string
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = InstanceFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/1?bar=baz", nil)
+	Middleware(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "/foo/1", got)
+}
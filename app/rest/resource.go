@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Resource describes a resource served by the application, registered with WithResource.
+type Resource struct {
+	// Name is the path segment the routes of the resource are served under (e.g. "foo" for /foo).
+	Name string
+
+	// Routes are the routes of the resource, such as the ones returned by CRUD.
+	Routes []Route
+
+	// ErrorHandler handles the errors of the resource, including the panics of its routes.
+	ErrorHandler errorHandler
+
+	// Middleware wraps the routes of the resource, the first one being the outermost.
+	Middleware []Middleware
+}
+
+// Route is a route of a resource.
+type Route struct {
+	// Method is the HTTP method of the route.
+	Method string
+
+	// Path is the path of the route relative to the resource, empty for the resource itself
+	// (e.g. "/{id}" for /foo/{id}). Wildcards are read with http.Request.PathValue.
+	Path string
+
+	// Handler serves the requests of the route.
+	Handler http.HandlerFunc
+
+	// Errors are examples of the errors the route fails with. The OpenAPI document describes the error
+	// responses of the route with the problems they translate into, including the data added by matchers.
+	// When empty, every problem of the error handler of the resource is described.
+	Errors []error
+}
+
+// errorLister is implemented by the handlers able to give examples of the errors their operations fail with,
+// the operations being named after the methods of the handler (e.g. "Get").
+type errorLister interface {
+	Errors(operation string) []error
+}
+
+// Middleware wraps a handler with additional behavior.
+type Middleware func(next http.Handler) http.Handler
+
+// CRUD returns the routes to list, create, get, update, patch and delete the items of a resource,
+// identified by the {id} wildcard. The routes carry the errors of their operation when the handler lists them.
+func CRUD(h handler) []Route {
+	errs := func(string) []error { return nil }
+	if l, ok := h.(errorLister); ok {
+		errs = l.Errors
+	}
+
+	return []Route{
+		{Method: http.MethodGet, Handler: h.List, Errors: errs("List")},
+		{Method: http.MethodPost, Handler: h.Create, Errors: errs("Create")},
+		{Method: http.MethodGet, Path: "/{id}", Handler: h.Get, Errors: errs("Get")},
+		{Method: http.MethodPut, Path: "/{id}", Handler: h.Update, Errors: errs("Update")},
+		{Method: http.MethodPatch, Path: "/{id}", Handler: h.Patch, Errors: errs("Patch")},
+		{Method: http.MethodDelete, Path: "/{id}", Handler: h.Delete, Errors: errs("Delete")},
+	}
+}
+
+// WithResource is an option to serve a resource. The resources are registered in the order
+// of the options, and the application fails to initialize if their routes conflict.
+func WithResource(res Resource) Option {
+	return func(app *App) {
+		app.resources = append(app.resources, res)
+	}
+}
+
+// validate checks that the resource can be registered.
+func (res Resource) validate() error {
+	if res.Name == "" || strings.ContainsAny(res.Name, "/ {}") {
+		return fmt.Errorf("name '%s' must be a single path segment", res.Name)
+	}
+
+	if res.ErrorHandler == nil {
+		return errors.New("error handler is required")
+	}
+
+	if len(res.Routes) == 0 {
+		return errors.New("at least one route is required")
+	}
+
+	for _, route := range res.Routes {
+		if route.Handler == nil {
+			return fmt.Errorf("route '%s %s' has no handler", route.Method, route.Path)
+		}
+	}
+	return nil
+}
+
+// registerResource registers the routes of a resource under its path, following the prefix,
+// wrapped by its middleware. The error handler of the resource handles the panics of its routes and middleware.
+func (app *App) registerResource(mux *http.ServeMux, prefix string, res Resource) error {
+	if err := res.validate(); err != nil {
+		return err
+	}
+
+	for _, route := range res.Routes {
+		var h http.Handler = route.Handler
+		for i := len(res.Middleware) - 1; i >= 0; i-- {
+			h = res.Middleware[i](h)
+		}
+
+		pattern := route.Method + " " + prefix + "/" + res.Name + route.Path
+		if err := handle(mux, pattern, app.recoverer(res.ErrorHandler, h.ServeHTTP)); err != nil {
+			return fmt.Errorf("could not register route '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// handle registers the handler for the pattern, returning the invalid and conflicting patterns
+// the mux panics on as errors.
+func handle(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+
+	mux.Handle(pattern, h)
+	return nil
+}
@@ -9,39 +9,361 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type handler interface {
 	Get(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Patch(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type errorHandler interface {
+	Handle(ctx context.Context, w http.ResponseWriter, err error)
+}
+
+// pinger is implemented by the dependencies whose availability is checked for readiness.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// translator translates the errors of a dependency into the problem reported for it.
+type translator interface {
+	Translate(ctx context.Context, err error) problem.Problem
+}
+
+// dependency is a dependency of the application checked for readiness.
+type dependency struct {
+	name       string
+	pinger     pinger
+	translator translator
+}
+
+// Option applies custom behavior to the application.
+type Option func(app *App)
+
+// WithMetrics is an option to collect the metrics of the application in the registry,
+// shared with the other components of the application, such as the error handlers.
+// Without it, the application collects its metrics in a registry of its own.
+func WithMetrics(registry *prometheus.Registry) Option {
+	return func(app *App) {
+		app.metrics = registry
+	}
 }
 
+// WithDependency is an option to check the availability of a dependency, such as a repository,
+// for the readiness of the application. The errors of the dependency are reported in the readiness
+// response as translated by the translator (usually the error handler of the resource using it),
+// so that its internals are not leaked.
+func WithDependency(name string, p pinger, t translator) Option {
+	return func(app *App) {
+		app.dependencies = append(app.dependencies, dependency{name: name, pinger: p, translator: t})
+	}
+}
+
+// WithTracerProvider is an option to trace the requests with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(app *App) {
+		app.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// tracerName is the name of the tracer of the application.
+const tracerName = "github.com/alesr/resterrdemo/app/rest"
+
+// errPanic is the error passed to the error handlers when a handler panics.
+// Panics are never expected, so the error is not mapped and results in an internal server error.
+var errPanic = errors.New("handler panicked")
+
 // App implements the transport layer by running an HTTP server.
 type App struct {
 	logger     *slog.Logger
 	server     *http.Server
-	fooHandler handler
-	barHandler handler
+	resources  []Resource
+	versions   []Version
+	errHandler errorHandler
+
+	metrics         *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	panics          atomic.Uint64
+
+	// tracer starts the span of each request, continuing the trace of the client
+	// propagated in the W3C trace context headers, if any.
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	// draining is set when the application starts shutting down, to fail the readiness checks
+	// while the requests in flight are drained.
+	draining      atomic.Bool
+	shutdownDelay time.Duration
+
+	dependencies     []dependency
+	readinessTimeout time.Duration
 }
 
-// NewApp instantiates a new App struct.
-func NewApp(logger *slog.Logger, addr string, fooHdler, barHdler handler) (*App, error) {
+// NewApp instantiates a new App struct, serving as configured the resources registered with WithResource,
+// and the versions of the API registered with WithVersion. The OpenAPI document describing them
+// is generated at once, and served at /openapi.json.
+func NewApp(logger *slog.Logger, cfg config.Server, opts ...Option) (*App, error) {
 	app := App{
-		logger:     logger.WithGroup("rest-app"),
-		fooHandler: fooHdler,
-		barHandler: barHdler,
+		logger:           logger.WithGroup("rest-app"),
+		shutdownDelay:    cfg.ShutdownDelay,
+		readinessTimeout: cfg.ReadinessTimeout,
+		tracer:           tracing.Tracer(nil, tracerName),
+		propagator:       propagation.TraceContext{},
+	}
+
+	for _, o := range opts {
+		o(&app)
+	}
+
+	if app.metrics == nil {
+		app.metrics = prometheus.NewRegistry()
+	}
+
+	app.requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route and status.",
+	}, []string{"method", "route", "status"})
+	app.requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests, by route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	panicsTotal := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "http_handler_panics_total",
+		Help: "Handler panics recovered.",
+	}, func() float64 { return float64(app.panics.Load()) })
+
+	for _, c := range []prometheus.Collector{app.requestsTotal, app.requestDuration, panicsTotal} {
+		if err := app.metrics.Register(c); err != nil {
+			return nil, fmt.Errorf("could not register metrics: %w", err)
+		}
+	}
+
+	errHandler, err := problem.NewHandler(logger, errMap)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize routing error handler: %w", err)
+	}
+	app.errHandler = errHandler
+
+	doc, err := app.openAPI()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate OpenAPI document: %w", err)
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /foo", app.fooHandler.Get)
-	mux.HandleFunc("GET /bar", app.barHandler.Get)
+	mux.HandleFunc("GET /healthz", app.alive)
+	mux.HandleFunc("GET /readyz", app.ready)
+	mux.Handle("GET /metrics", promhttp.HandlerFor(app.metrics, promhttp.HandlerOpts{
+		ErrorLog: slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+	}))
+	mux.HandleFunc("GET /openapi.json", serveOpenAPI(doc))
+
+	for _, res := range app.resources {
+		if err := app.registerResource(mux, "", res); err != nil {
+			return nil, fmt.Errorf("could not register resource '%s': %w", res.Name, err)
+		}
+	}
+
+	var defaultVersion string
+	for _, v := range app.versions {
+		if v.Default && defaultVersion != "" {
+			return nil, fmt.Errorf("could not register version '%s': version '%s' is already the default one", v.Name, defaultVersion)
+		}
+
+		if v.Default {
+			defaultVersion = v.Name
+		}
+
+		if err := app.registerVersion(mux, v); err != nil {
+			return nil, fmt.Errorf("could not register version '%s': %w", v.Name, err)
+		}
+	}
 
 	app.server = &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:              cfg.Addr,
+		Handler:           requestid.Middleware(problem.Middleware(app.instrument(mux, unmatched(mux, errHandler)))),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
 	return &app, nil
 }
 
+// recoverer recovers from the panics of next, logging them with their stack trace,
+// and passes an error to the error handler of the resource to respond to the client.
+// Panics aborting the handler on purpose (http.ErrAbortHandler) are left to the server.
+func (app *App) recoverer(errHandler errorHandler, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			app.panics.Add(1)
+
+			app.logger.ErrorContext(
+				r.Context(),
+				"Recovered from handler panic.",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Any("panic", v),
+				slog.String("stack", string(debug.Stack())),
+			)
+			errHandler.Handle(r.Context(), w, fmt.Errorf("%w: %v", errPanic, v))
+		}()
+
+		next(w, r)
+	}
+}
+
+// Panics returns the number of handler panics recovered since the application started.
+func (app *App) Panics() uint64 {
+	return app.panics.Load()
+}
+
+// unmatched serves the requests with the mux, and passes an error to the error handler
+// instead of the plain text responses of the mux for the requests that do not match any route.
+// The Allow header set by the mux for the methods not allowed is kept.
+func unmatched(mux *http.ServeMux, errHandler errorHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		uw := unmatchedWriter{ResponseWriter: w}
+		mux.ServeHTTP(&uw, r)
+
+		switch uw.status {
+		case http.StatusNotFound:
+			errHandler.Handle(r.Context(), w, fmt.Errorf("could not route '%s %s': %w", r.Method, r.URL.Path, errRouteNotFound))
+		case http.StatusMethodNotAllowed:
+			errHandler.Handle(r.Context(), w, fmt.Errorf("could not route '%s %s': %w", r.Method, r.URL.Path, errMethodNotAllowed))
+		}
+	})
+}
+
+// unmatchedWriter discards the not found and method not allowed responses written by the mux,
+// and records their status. Other responses, such as redirects, are written as they are.
+type unmatchedWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *unmatchedWriter) WriteHeader(status int) {
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.status != 0 {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// instrument counts the requests served by next and observes their duration,
+// labelled by the route of the mux they match. It also traces each request in a server span,
+// child of the span propagated by the client, if any.
+func (app *App) instrument(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		_, route := mux.Handler(r)
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := app.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := app.tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := statusWriter{ResponseWriter: w}
+		next.ServeHTTP(&sw, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.status()))
+		if sw.status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status()))
+		}
+
+		status := strconv.Itoa(sw.status())
+		app.requestsTotal.WithLabelValues(r.Method, route, status).Inc()
+		app.requestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter records the status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.code == 0 {
+		w.code = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
 // Run starts the application, serving on the specified address and port as provided in the configuration.
 func (app *App) Run() error {
 	app.logger.Info("Starting REST demo app.", slog.String("addr", app.server.Addr))
@@ -56,11 +378,31 @@ func (app *App) Run() error {
 	return nil
 }
 
+// ServeHTTP dispatches the request to the handler registered for its route,
+// which makes App usable wherever an http.Handler is expected (e.g. httptest servers).
+func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	app.server.Handler.ServeHTTP(w, r)
+}
+
 // Shutdown gracefully shuts down the server.
-// The shutdown timeout can be controlled by the context passed as an argument.
+// The readiness checks fail from the start of the shutdown, and the server keeps serving
+// for the configured shutdown delay, so that load balancers stop routing requests to it.
+// The server then stops accepting connections and drains the requests in flight
+// until the deadline of the context passed as an argument, after which the remaining connections are closed.
 func (app *App) Shutdown(ctx context.Context) error {
+	app.draining.Store(true)
+	app.logger.InfoContext(ctx, "Server draining.", slog.Duration("delay", app.shutdownDelay))
+
+	select {
+	case <-time.After(app.shutdownDelay):
+	case <-ctx.Done():
+	}
+
 	app.logger.InfoContext(ctx, "Server shutting down")
 	if err := app.server.Shutdown(ctx); err != nil {
+		if cerr := app.server.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
 		return fmt.Errorf("could not shut down server: %w", err)
 	}
 	return nil
@@ -1,40 +1,124 @@
 package rest
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type handlerMock struct {
-	getFunc func(w http.ResponseWriter, r *http.Request)
+	getFunc    func(w http.ResponseWriter, r *http.Request)
+	listFunc   func(w http.ResponseWriter, r *http.Request)
+	createFunc func(w http.ResponseWriter, r *http.Request)
+	updateFunc func(w http.ResponseWriter, r *http.Request)
+	patchFunc  func(w http.ResponseWriter, r *http.Request)
+	deleteFunc func(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *handlerMock) Get(w http.ResponseWriter, r *http.Request) {
 	h.getFunc(w, r)
 }
 
+func (h *handlerMock) List(w http.ResponseWriter, r *http.Request) {
+	h.listFunc(w, r)
+}
+
+func (h *handlerMock) Create(w http.ResponseWriter, r *http.Request) {
+	h.createFunc(w, r)
+}
+
+func (h *handlerMock) Update(w http.ResponseWriter, r *http.Request) {
+	h.updateFunc(w, r)
+}
+
+func (h *handlerMock) Patch(w http.ResponseWriter, r *http.Request) {
+	h.patchFunc(w, r)
+}
+
+func (h *handlerMock) Delete(w http.ResponseWriter, r *http.Request) {
+	h.deleteFunc(w, r)
+}
+
+type errorHandlerMock struct {
+	handleFunc func(ctx context.Context, w http.ResponseWriter, err error)
+}
+
+func (h *errorHandlerMock) Handle(ctx context.Context, w http.ResponseWriter, err error) {
+	h.handleFunc(ctx, w, err)
+}
+
+type pingerMock struct {
+	pingFunc func(ctx context.Context) error
+}
+
+func (p *pingerMock) Ping(ctx context.Context) error {
+	return p.pingFunc(ctx)
+}
+
+// recordingHandlerMock returns a handler mock that writes the name
+// of the called method and the resource name in the response headers.
+func recordingHandlerMock(resource string) *handlerMock {
+	record := func(method string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Resource", resource)
+			w.Header().Set("X-Method", method)
+			w.Header().Set("X-ID", r.PathValue("id"))
+		}
+	}
+
+	return &handlerMock{
+		getFunc:    record("Get"),
+		listFunc:   record("List"),
+		createFunc: record("Create"),
+		updateFunc: record("Update"),
+		patchFunc:  record("Patch"),
+		deleteFunc: record("Delete"),
+	}
+}
+
+// crud returns the option registering the resource with the CRUD routes of the handler.
+func crud(name string, h handler, errHandler errorHandler) Option {
+	return WithResource(Resource{Name: name, Routes: CRUD(h), ErrorHandler: errHandler})
+}
+
 func TestNewApp(t *testing.T) {
 	logger := noopLogger()
-	port := "dummy-port"
-	fooHandler := &handlerMock{}
-	barHandler := &handlerMock{}
+	cfg := config.Server{Addr: "dummy-port", ReadTimeout: time.Second, WriteTimeout: 2 * time.Second}
 
-	app, err := NewApp(logger, port, fooHandler, barHandler)
+	app, err := NewApp(logger, cfg, crud("foo", &handlerMock{}, &errorHandlerMock{}), crud("bar", &handlerMock{}, &errorHandlerMock{}))
 	require.NoError(t, err)
 	require.NotNil(t, app)
 
 	assert.NotNil(t, app.logger)
-	assert.Equal(t, port, app.server.Addr)
-	assert.Equal(t, fooHandler, app.fooHandler)
-	assert.Equal(t, barHandler, app.barHandler)
+	assert.Equal(t, cfg.Addr, app.server.Addr)
+	assert.Equal(t, cfg.ReadTimeout, app.server.ReadTimeout)
+	assert.Equal(t, cfg.WriteTimeout, app.server.WriteTimeout)
+	require.Len(t, app.resources, 2)
+	assert.Equal(t, "foo", app.resources[0].Name)
+	assert.Equal(t, "bar", app.resources[1].Name)
 }
 
 func TestApp_Run_Shutdown(t *testing.T) {
@@ -52,7 +136,7 @@ func TestApp_Run_Shutdown(t *testing.T) {
 		},
 	}
 
-	app, err := NewApp(logger, ":8081", &fooHandler, &barHandler)
+	app, err := NewApp(logger, config.Server{Addr: ":8081"}, crud("foo", &fooHandler, &errorHandlerMock{}), crud("bar", &barHandler, &errorHandlerMock{}))
 	require.NoError(t, err)
 	require.NotNil(t, app)
 
@@ -65,14 +149,14 @@ func TestApp_Run_Shutdown(t *testing.T) {
 	// Wait for the server to start
 	time.Sleep(100 * time.Millisecond)
 
-	// Test /foo route
-	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	// Test /foo/{id} route
+	req := httptest.NewRequest(http.MethodGet, "/foo/1", nil)
 	w := httptest.NewRecorder()
 	app.server.Handler.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 
-	// Test /bar route
-	req = httptest.NewRequest(http.MethodGet, "/bar", nil)
+	// Test /bar/{id} route
+	req = httptest.NewRequest(http.MethodGet, "/bar/1", nil)
 	w = httptest.NewRecorder()
 	app.server.Handler.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusTeapot, w.Result().StatusCode)
@@ -84,6 +168,979 @@ func TestApp_Run_Shutdown(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestApp_Shutdown_Drain(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	fooHandler := &handlerMock{
+		getFunc: func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusOK)
+		},
+	}
+
+	cfg := config.Server{Addr: "127.0.0.1:8082", ShutdownDelay: 50 * time.Millisecond}
+
+	app, err := NewApp(noopLogger(), cfg, crud("foo", fooHandler, &errorHandlerMock{}), crud("bar", &handlerMock{}, &errorHandlerMock{}))
+	require.NoError(t, err)
+
+	go func() {
+		assert.NoError(t, app.Run())
+	}()
+	waitForServer(t, "http://"+cfg.Addr+"/readyz")
+
+	// A request is in flight when the shutdown starts.
+	resp := make(chan *http.Response, 1)
+	go func() {
+		r, err := http.Get("http://" + cfg.Addr + "/foo/1")
+		assert.NoError(t, err)
+		resp <- r
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		shutdownErr <- app.Shutdown(ctx)
+	}()
+
+	// The readiness checks fail while the server keeps serving for the shutdown delay.
+	assert.Eventually(t, func() bool {
+		r, err := http.Get("http://" + cfg.Addr + "/readyz")
+		if err != nil {
+			return false
+		}
+		defer r.Body.Close()
+		return r.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 5*time.Millisecond)
+
+	// The request in flight is drained before the shutdown completes.
+	close(release)
+
+	r := <-resp
+	defer r.Body.Close()
+	assert.Equal(t, http.StatusOK, r.StatusCode)
+
+	assert.NoError(t, <-shutdownErr)
+}
+
+func TestApp_Shutdown_Deadline(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	fooHandler := &handlerMock{
+		getFunc: func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		},
+	}
+
+	cfg := config.Server{Addr: "127.0.0.1:8083"}
+
+	app, err := NewApp(noopLogger(), cfg, crud("foo", fooHandler, &errorHandlerMock{}), crud("bar", &handlerMock{}, &errorHandlerMock{}))
+	require.NoError(t, err)
+
+	go func() {
+		assert.NoError(t, app.Run())
+	}()
+	waitForServer(t, "http://"+cfg.Addr+"/readyz")
+
+	reqErr := make(chan error, 1)
+	go func() {
+		r, err := http.Get("http://" + cfg.Addr + "/foo/1")
+		if err == nil {
+			r.Body.Close()
+		}
+		reqErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The connections still in flight at the deadline are closed.
+	err = app.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Error(t, <-reqErr)
+}
+
+func TestApp_Ready(t *testing.T) {
+	t.Parallel()
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, crud("foo", &handlerMock{}, &errorHandlerMock{}), crud("bar", &handlerMock{}, &errorHandlerMock{}))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.JSONEq(t, `{"status": "ready"}`, w.Body.String())
+
+	// Shutting down a server that is not running only fails the readiness checks.
+	require.NoError(t, app.Shutdown(context.Background()))
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+	assert.Equal(t, problem.ContentType, w.Result().Header.Get("Content-Type"))
+
+	var got problem.Problem
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+	assert.Equal(t, typeNotReady, got.Type)
+}
+
+func TestApp_Health(t *testing.T) {
+	t.Parallel()
+
+	errStoreDown := errors.New("dial tcp 10.0.0.1:5432: connection refused")
+	errStoreUnavailable := errors.New("store unavailable")
+
+	translator, err := problem.NewHandler(noopLogger(), problem.Map{
+		{Err: errStoreUnavailable, Value: problem.Problem{Status: http.StatusServiceUnavailable, Detail: "the store is unavailable"}},
+	})
+	require.NoError(t, err)
+
+	available := &pingerMock{pingFunc: func(ctx context.Context) error { return nil }}
+	mapped := &pingerMock{pingFunc: func(ctx context.Context) error {
+		return fmt.Errorf("could not ping store: '%s': %w", errStoreDown, errStoreUnavailable)
+	}}
+	unmapped := &pingerMock{pingFunc: func(ctx context.Context) error { return errStoreDown }}
+	stuck := &pingerMock{pingFunc: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	testCases := []struct {
+		name           string
+		path           string
+		opts           []Option
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "alive",
+			path:           "/healthz",
+			opts:           []Option{WithDependency("foo-storage", unmapped, translator)},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status": "alive"}`,
+		},
+		{
+			name:           "ready without dependencies",
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status": "ready"}`,
+		},
+		{
+			name: "ready with available dependencies",
+			path: "/readyz",
+			opts: []Option{
+				WithDependency("foo-storage", available, translator),
+				WithDependency("bar-storage", available, translator),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"status": "ready",
+				"dependencies": {
+					"foo-storage": {"status": "available"},
+					"bar-storage": {"status": "available"}
+				}
+			}`,
+		},
+		{
+			name: "mapped dependency error",
+			path: "/readyz",
+			opts: []Option{
+				WithDependency("foo-storage", mapped, translator),
+				WithDependency("bar-storage", available, translator),
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: `{
+				"status": "not ready",
+				"dependencies": {
+					"foo-storage": {
+						"status": "unavailable",
+						"problem": {"type": "about:blank", "status": 503, "title": "Service Unavailable", "detail": "the store is unavailable"}
+					},
+					"bar-storage": {"status": "available"}
+				}
+			}`,
+		},
+		{
+			name:           "unmapped dependency error",
+			path:           "/readyz",
+			opts:           []Option{WithDependency("foo-storage", unmapped, translator)},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: `{
+				"status": "not ready",
+				"dependencies": {
+					"foo-storage": {
+						"status": "unavailable",
+						"problem": {"type": "about:blank", "status": 500, "title": "Internal Server Error", "detail": "something went wrong"}
+					}
+				}
+			}`,
+		},
+		{
+			name:           "dependency check timeout",
+			path:           "/readyz",
+			opts:           []Option{WithDependency("foo-storage", stuck, translator)},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: `{
+				"status": "not ready",
+				"dependencies": {
+					"foo-storage": {
+						"status": "unavailable",
+						"problem": {"type": "about:blank", "status": 500, "title": "Internal Server Error", "detail": "something went wrong"}
+					}
+				}
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.Server{Addr: ":0", ReadinessTimeout: 20 * time.Millisecond}
+
+			app, err := NewApp(noopLogger(), cfg, tc.opts...)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, "application/json", w.Result().Header.Get("Content-Type"))
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			assert.NotContains(t, w.Body.String(), "connection refused")
+		})
+	}
+}
+
+func TestApp_Metrics(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	fooHandler := recordingHandlerMock("foo")
+	fooHandler.getFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, crud("foo", fooHandler, &errorHandlerMock{}), crud("bar", recordingHandlerMock("bar"), &errorHandlerMock{}), WithMetrics(registry))
+	require.NoError(t, err)
+
+	for _, path := range []string{"/foo/1", "/foo/2", "/bar", "/baz"} {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP http_requests_total HTTP requests served, by route and status.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/bar",status="200"} 1
+http_requests_total{method="GET",route="/foo/{id}",status="404"} 2
+http_requests_total{method="GET",route="unmatched",status="404"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"))
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.True(t, strings.HasPrefix(w.Result().Header.Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/foo/{id}",status="404"} 2`)
+	assert.Contains(t, w.Body.String(), `http_request_duration_seconds_count{method="GET",route="/bar",status="200"} 1`)
+}
+
+func TestApp_Tracing(t *testing.T) {
+	t.Parallel()
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	var handlerSpan trace.SpanContext
+
+	fooHandler := recordingHandlerMock("foo")
+	fooHandler.getFunc = func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, crud("foo", fooHandler, &errorHandlerMock{}), crud("bar", recordingHandlerMock("bar"), &errorHandlerMock{}),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))),
+	)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/bar", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	t.Run("propagated trace", func(t *testing.T) {
+		span := spans[0]
+
+		assert.Equal(t, "GET /foo/{id}", span.Name)
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+		assert.Equal(t, traceID, span.SpanContext.TraceID().String())
+		assert.Equal(t, parentSpanID, span.Parent.SpanID().String())
+		assert.True(t, span.Parent.IsRemote())
+		assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+
+		assert.Subset(t, span.Attributes, []attribute.KeyValue{
+			attribute.String("http.request.method", http.MethodGet),
+			attribute.String("http.route", "/foo/{id}"),
+			attribute.String("url.path", "/foo/1"),
+			attribute.Int("http.response.status_code", http.StatusServiceUnavailable),
+		})
+		assert.Equal(t, codes.Error, span.Status.Code)
+	})
+
+	t.Run("new trace", func(t *testing.T) {
+		span := spans[1]
+
+		assert.Equal(t, "GET /bar", span.Name)
+		assert.NotEqual(t, traceID, span.SpanContext.TraceID().String())
+		assert.False(t, span.Parent.IsValid())
+		assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+		assert.Equal(t, codes.Unset, span.Status.Code)
+	})
+}
+
+func TestApp_Routes(t *testing.T) {
+	t.Parallel()
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, crud("foo", recordingHandlerMock("foo"), &errorHandlerMock{}), crud("bar", recordingHandlerMock("bar"), &errorHandlerMock{}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		method           string
+		path             string
+		expectedResource string
+		expectedMethod   string
+		expectedID       string
+	}{
+		{method: http.MethodGet, path: "/foo", expectedResource: "foo", expectedMethod: "List"},
+		{method: http.MethodPost, path: "/foo", expectedResource: "foo", expectedMethod: "Create"},
+		{method: http.MethodGet, path: "/foo/1", expectedResource: "foo", expectedMethod: "Get", expectedID: "1"},
+		{method: http.MethodPut, path: "/foo/1", expectedResource: "foo", expectedMethod: "Update", expectedID: "1"},
+		{method: http.MethodPatch, path: "/foo/1", expectedResource: "foo", expectedMethod: "Patch", expectedID: "1"},
+		{method: http.MethodDelete, path: "/foo/1", expectedResource: "foo", expectedMethod: "Delete", expectedID: "1"},
+		{method: http.MethodGet, path: "/bar", expectedResource: "bar", expectedMethod: "List"},
+		{method: http.MethodPost, path: "/bar", expectedResource: "bar", expectedMethod: "Create"},
+		{method: http.MethodGet, path: "/bar/2", expectedResource: "bar", expectedMethod: "Get", expectedID: "2"},
+		{method: http.MethodPut, path: "/bar/2", expectedResource: "bar", expectedMethod: "Update", expectedID: "2"},
+		{method: http.MethodPatch, path: "/bar/2", expectedResource: "bar", expectedMethod: "Patch", expectedID: "2"},
+		{method: http.MethodDelete, path: "/bar/2", expectedResource: "bar", expectedMethod: "Delete", expectedID: "2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+
+			app.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedResource, w.Header().Get("X-Resource"))
+			assert.Equal(t, tc.expectedMethod, w.Header().Get("X-Method"))
+			assert.Equal(t, tc.expectedID, w.Header().Get("X-ID"))
+		})
+	}
+}
+
+func TestApp_Resource(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	middleware := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	bazErrHandler := &errorHandlerMock{
+		handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			w.WriteHeader(http.StatusTeapot)
+		},
+	}
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"},
+		crud("foo", recordingHandlerMock("foo"), &errorHandlerMock{}),
+		WithResource(Resource{
+			Name: "baz",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/{id}/qux", Handler: func(w http.ResponseWriter, r *http.Request) {
+					order = append(order, "handler")
+					w.Header().Set("X-ID", r.PathValue("id"))
+				}},
+				{Method: http.MethodPost, Handler: func(w http.ResponseWriter, r *http.Request) {
+					panic("baz exploded")
+				}},
+			},
+			ErrorHandler: bazErrHandler,
+			Middleware:   []Middleware{middleware("outer"), middleware("inner")},
+		}),
+	)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/baz/7/qux", nil))
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "7", w.Header().Get("X-ID"))
+	assert.Equal(t, []string{"outer", "inner", "handler"}, order)
+
+	// The panics of the routes are handled by the error handler of the resource.
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/baz", nil))
+	assert.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+
+	// The other resources are served as before.
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/foo/1", nil))
+	assert.Equal(t, "foo", w.Header().Get("X-Resource"))
+	assert.Equal(t, "Get", w.Header().Get("X-Method"))
+}
+
+func TestNewApp_InvalidResource(t *testing.T) {
+	t.Parallel()
+
+	get := Route{Method: http.MethodGet, Handler: func(w http.ResponseWriter, r *http.Request) {}}
+
+	testCases := []struct {
+		name          string
+		resources     []Resource
+		expectedError string
+	}{
+		{
+			name:          "empty name",
+			resources:     []Resource{{Routes: []Route{get}, ErrorHandler: &errorHandlerMock{}}},
+			expectedError: "name '' must be a single path segment",
+		},
+		{
+			name:          "nested name",
+			resources:     []Resource{{Name: "foo/bar", Routes: []Route{get}, ErrorHandler: &errorHandlerMock{}}},
+			expectedError: "name 'foo/bar' must be a single path segment",
+		},
+		{
+			name:          "no error handler",
+			resources:     []Resource{{Name: "foo", Routes: []Route{get}}},
+			expectedError: "error handler is required",
+		},
+		{
+			name:          "no routes",
+			resources:     []Resource{{Name: "foo", ErrorHandler: &errorHandlerMock{}}},
+			expectedError: "at least one route is required",
+		},
+		{
+			name:          "route without handler",
+			resources:     []Resource{{Name: "foo", Routes: []Route{{Method: http.MethodGet, Path: "/{id}"}}, ErrorHandler: &errorHandlerMock{}}},
+			expectedError: "route 'GET /{id}' has no handler",
+		},
+		{
+			name: "duplicate resource",
+			resources: []Resource{
+				{Name: "foo", Routes: []Route{get}, ErrorHandler: &errorHandlerMock{}},
+				{Name: "foo", Routes: []Route{get}, ErrorHandler: &errorHandlerMock{}},
+			},
+			expectedError: "could not register resource 'foo': could not register route 'GET /foo'",
+		},
+		{
+			name:          "conflict with health endpoint",
+			resources:     []Resource{{Name: "healthz", Routes: []Route{get}, ErrorHandler: &errorHandlerMock{}}},
+			expectedError: "could not register resource 'healthz': could not register route 'GET /healthz'",
+		},
+		{
+			name:          "invalid pattern",
+			resources:     []Resource{{Name: "foo", Routes: []Route{{Method: http.MethodGet, Path: "/{id", Handler: get.Handler}}, ErrorHandler: &errorHandlerMock{}}},
+			expectedError: "could not register route 'GET /foo/{id'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []Option
+			for _, res := range tc.resources {
+				opts = append(opts, WithResource(res))
+			}
+
+			app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, opts...)
+			require.Error(t, err)
+
+			assert.Nil(t, app)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestApp_Versions(t *testing.T) {
+	t.Parallel()
+
+	deprecation := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	versioned := func(version string) *handlerMock {
+		return &handlerMock{
+			getFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Version", version)
+			},
+		}
+	}
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"},
+		WithVersion(Version{
+			Name:        "v1",
+			Resources:   []Resource{{Name: "foo", Routes: CRUD(versioned("v1")), ErrorHandler: &errorHandlerMock{}}},
+			Default:     true,
+			Deprecation: deprecation,
+			Sunset:      sunset,
+		}),
+		WithVersion(Version{
+			Name:      "v2",
+			Resources: []Resource{{Name: "foo", Routes: CRUD(versioned("v2")), ErrorHandler: &errorHandlerMock{}}},
+		}),
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		path                string
+		expectedVersion     string
+		expectedDeprecation string
+		expectedSunset      string
+	}{
+		{
+			path:                "/v1/foo/1",
+			expectedVersion:     "v1",
+			expectedDeprecation: "@1790812800",
+			expectedSunset:      "Thu, 01 Apr 2027 00:00:00 GMT",
+		},
+		{
+			path:                "/foo/1",
+			expectedVersion:     "v1",
+			expectedDeprecation: "@1790812800",
+			expectedSunset:      "Thu, 01 Apr 2027 00:00:00 GMT",
+		},
+		{
+			path:            "/v2/foo/1",
+			expectedVersion: "v2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedVersion, w.Header().Get("X-Version"))
+			assert.Equal(t, tc.expectedDeprecation, w.Header().Get(HeaderDeprecation))
+			assert.Equal(t, tc.expectedSunset, w.Header().Get(HeaderSunset))
+		})
+	}
+
+	t.Run("unknown version", func(t *testing.T) {
+		t.Parallel()
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v3/foo/1", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+}
+
+func TestNewApp_InvalidVersion(t *testing.T) {
+	t.Parallel()
+
+	foo := Resource{Name: "foo", Routes: CRUD(&handlerMock{}), ErrorHandler: &errorHandlerMock{}}
+
+	testCases := []struct {
+		name          string
+		versions      []Version
+		expectedError string
+	}{
+		{
+			name:          "empty name",
+			versions:      []Version{{Resources: []Resource{foo}}},
+			expectedError: "could not register version '': name '' must be a single path segment",
+		},
+		{
+			name:          "no resources",
+			versions:      []Version{{Name: "v1"}},
+			expectedError: "could not register version 'v1': at least one resource is required",
+		},
+		{
+			name: "sunset before deprecation",
+			versions: []Version{{
+				Name:        "v1",
+				Resources:   []Resource{foo},
+				Deprecation: time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC),
+				Sunset:      time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+			}},
+			expectedError: "could not register version 'v1': sunset must not be before deprecation",
+		},
+		{
+			name: "several default versions",
+			versions: []Version{
+				{Name: "v1", Resources: []Resource{foo}, Default: true},
+				{Name: "v2", Resources: []Resource{foo}, Default: true},
+			},
+			expectedError: "could not register version 'v2': version 'v1' is already the default one",
+		},
+		{
+			name:          "invalid resource",
+			versions:      []Version{{Name: "v1", Resources: []Resource{{Name: "foo"}}}},
+			expectedError: "could not register version 'v1': could not register resource 'foo': error handler is required",
+		},
+		{
+			name:          "duplicate version",
+			versions:      []Version{{Name: "v1", Resources: []Resource{foo}}, {Name: "v1", Resources: []Resource{foo}}},
+			expectedError: "could not register version 'v1': could not register resource 'foo': could not register route 'GET /v1/foo'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []Option
+			for _, v := range tc.versions {
+				opts = append(opts, WithVersion(v))
+			}
+
+			app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, opts...)
+			require.Error(t, err)
+
+			assert.Nil(t, app)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestApp_Unmatched(t *testing.T) {
+	t.Parallel()
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"}, crud("foo", recordingHandlerMock("foo"), &errorHandlerMock{}), crud("bar", recordingHandlerMock("bar"), &errorHandlerMock{}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedType   string
+		expectedAllow  string
+	}{
+		{
+			name:           "unknown path",
+			method:         http.MethodGet,
+			path:           "/baz",
+			expectedStatus: http.StatusNotFound,
+			expectedType:   typeRouteNotFound,
+		},
+		{
+			name:           "unknown nested path",
+			method:         http.MethodGet,
+			path:           "/foo/1/baz",
+			expectedStatus: http.StatusNotFound,
+			expectedType:   typeRouteNotFound,
+		},
+		{
+			name:           "method not allowed on collection",
+			method:         http.MethodDelete,
+			path:           "/foo",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedType:   typeMethodNotAllowed,
+			expectedAllow:  "GET, HEAD, POST",
+		},
+		{
+			name:           "method not allowed on item",
+			method:         http.MethodPost,
+			path:           "/bar/1",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedType:   typeMethodNotAllowed,
+			expectedAllow:  "DELETE, GET, HEAD, PATCH, PUT",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+
+			app.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, problem.ContentType, w.Result().Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedAllow, w.Result().Header.Get("Allow"))
+			assert.Empty(t, w.Header().Get("X-Resource"))
+
+			var got problem.Problem
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+
+			assert.Equal(t, tc.expectedType, got.Type)
+			assert.Equal(t, tc.expectedStatus, got.Status)
+			assert.Equal(t, tc.path, got.Instance)
+		})
+	}
+}
+
+func TestApp_Recover(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	fooErrHandler, err := problem.NewHandler(noopLogger(), nil)
+	require.NoError(t, err)
+
+	var barHandled error
+	barErrHandler := &errorHandlerMock{
+		handleFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			barHandled = err
+			w.WriteHeader(http.StatusTeapot)
+		},
+	}
+
+	fooHandler := &handlerMock{
+		getFunc: func(w http.ResponseWriter, r *http.Request) {
+			panic("foo exploded")
+		},
+		deleteFunc: func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		},
+	}
+
+	barHandler := &handlerMock{
+		getFunc: func(w http.ResponseWriter, r *http.Request) {
+			panic(errors.New("bar exploded"))
+		},
+	}
+
+	app, err := NewApp(logger, config.Server{Addr: ":0"}, crud("foo", fooHandler, fooErrHandler), crud("bar", barHandler, barErrHandler))
+	require.NoError(t, err)
+
+	// The error handler of the resource responds with the standard internal server error.
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/foo/1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"status": 500,
+		"title": "Internal Server Error",
+		"detail": "something went wrong",
+		"instance": "/foo/1",
+		"request-id": "`+w.Header().Get(requestid.Header)+`"
+	}`, w.Body.String())
+
+	var record struct {
+		Msg     string `json:"msg"`
+		RestApp struct {
+			Method string `json:"method"`
+			Path   string `json:"path"`
+			Panic  string `json:"panic"`
+			Stack  string `json:"stack"`
+		} `json:"rest-app"`
+	}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+
+	assert.Equal(t, "Recovered from handler panic.", record.Msg)
+	assert.Equal(t, http.MethodGet, record.RestApp.Method)
+	assert.Equal(t, "/foo/1", record.RestApp.Path)
+	assert.Equal(t, "foo exploded", record.RestApp.Panic)
+	assert.Contains(t, record.RestApp.Stack, "TestApp_Recover")
+
+	// Each resource handles the panics of its handler.
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bar/1", nil))
+
+	assert.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+	assert.ErrorIs(t, barHandled, errPanic)
+	assert.Equal(t, "handler panicked: bar exploded", barHandled.Error())
+
+	// Aborted handlers are left to the server.
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/foo/1", nil))
+	})
+
+	assert.Equal(t, uint64(2), app.Panics())
+}
+
+// waitForServer waits until the server answers requests on the given URL.
+func TestApp_OpenAPI(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo err")
+
+	fooErrHandler, err := problem.NewHandler(noopLogger(), problem.Map{
+		{Err: errFoo, Value: problem.Problem{Type: "https://example.com/foo/not-found", Status: http.StatusNotFound, Title: "Foo Not Found", Detail: "foo not found"}},
+	})
+	require.NoError(t, err)
+
+	errBazNotFound := domainerr.New(domainerr.KindNotFound, "baz.not_found", "baz not found")
+	errBazTaken := errors.New("baz taken")
+
+	bazErrHandler, err := problem.NewHandler(noopLogger(), problem.Map{
+		{Err: errBazNotFound, Value: problem.Problem{Type: "https://example.com/baz/not-found", Status: http.StatusNotFound, Detail: "baz not found"}},
+		{Err: errBazTaken, Value: problem.Problem{Type: "https://example.com/baz/taken", Status: http.StatusConflict, Detail: "baz taken"}},
+	}, problem.WithMatchers(problem.As(func(e *domainerr.Error) (problem.Problem, bool) {
+		id, ok := e.Metadata["id"]
+		if !ok {
+			return problem.Problem{}, false
+		}
+		return problem.Problem{Type: "https://example.com/baz/not-found", Status: http.StatusNotFound, Detail: "baz " + id + " not found", Extensions: map[string]any{"id": id}}, true
+	})))
+	require.NoError(t, err)
+
+	bazRoutes := []Route{
+		{Method: http.MethodGet, Path: "/{id}", Handler: func(http.ResponseWriter, *http.Request) {}, Errors: []error{errBazNotFound.With("id", "42")}},
+		{Method: http.MethodPost, Handler: func(http.ResponseWriter, *http.Request) {}, Errors: []error{errBazTaken, assert.AnError}},
+	}
+
+	app, err := NewApp(noopLogger(), config.Server{Addr: ":0"},
+		WithVersion(Version{
+			Name:        "v1",
+			Resources:   []Resource{{Name: "foo", Routes: CRUD(&handlerMock{}), ErrorHandler: fooErrHandler}},
+			Default:     true,
+			Deprecation: time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+		}),
+		WithVersion(Version{
+			Name: "v2",
+			Resources: []Resource{
+				{Name: "foo", Routes: CRUD(&handlerMock{}), ErrorHandler: fooErrHandler},
+				{Name: "bar", Routes: CRUD(&handlerMock{}), ErrorHandler: &errorHandlerMock{}},
+				{Name: "baz", Routes: bazRoutes, ErrorHandler: bazErrHandler},
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "application/json", w.Result().Header.Get("Content-Type"))
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "v2", doc.Info.Version)
+	assert.ElementsMatch(t, []string{
+		"/healthz", "/readyz", "/metrics", "/openapi.json",
+		"/foo", "/foo/{id}", "/v1/foo", "/v1/foo/{id}", "/v2/foo", "/v2/foo/{id}", "/v2/bar", "/v2/bar/{id}", "/v2/baz", "/v2/baz/{id}",
+	}, keys(doc.Paths))
+
+	get := doc.Paths["/v2/foo/{id}"]["get"]
+	assert.Equal(t, "get-v2-foo-id", get.OperationID)
+	assert.False(t, get.Deprecated)
+	assert.Equal(t, []openAPIParameter{{Name: "id", In: "path", Required: true, Schema: map[string]any{"type": "string"}}}, get.Parameters)
+	assert.ElementsMatch(t, []string{"200", "404", "500"}, keys(get.Responses))
+
+	notFound := get.Responses["404"]
+	assert.Equal(t, "Foo Not Found", notFound.Description)
+	assert.Equal(t, "foo not found", notFound.Content[problem.ContentType].Examples["not-found"].Value.Detail)
+	assert.Equal(t, "something went wrong", get.Responses["500"].Content[problem.ContentType].Examples["internal-server-error"].Value.Detail)
+
+	assert.True(t, doc.Paths["/v1/foo/{id}"]["get"].Deprecated)
+	assert.True(t, doc.Paths["/foo/{id}"]["get"].Deprecated)
+
+	create := doc.Paths["/v2/foo"]["post"]
+	assert.NotNil(t, create.RequestBody)
+	assert.ElementsMatch(t, []string{"201", "404", "500"}, keys(create.Responses))
+	assert.ElementsMatch(t, []string{"204", "404", "500"}, keys(doc.Paths["/v2/foo/{id}"]["delete"].Responses))
+
+	// The error handler of bar cannot list its problems, so only the internal server error is documented.
+	assert.ElementsMatch(t, []string{"200", "500"}, keys(doc.Paths["/v2/bar/{id}"]["get"].Responses))
+
+	// The routes listing their errors are documented with the problems of their errors only,
+	// including the members added by the matchers.
+	getBaz := doc.Paths["/v2/baz/{id}"]["get"]
+	assert.ElementsMatch(t, []string{"200", "404", "500"}, keys(getBaz.Responses))
+	bazNotFound := getBaz.Responses["404"].Content[problem.ContentType].Examples["not-found"].Value
+	assert.Equal(t, "baz 42 not found", bazNotFound.Detail)
+	assert.Equal(t, map[string]any{"id": "42"}, bazNotFound.Extensions)
+
+	assert.ElementsMatch(t, []string{"201", "409", "500"}, keys(doc.Paths["/v2/baz"]["post"].Responses))
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		given          string
+		expectedPath   string
+		expectedParams []string
+	}{
+		{given: "/v1/foo", expectedPath: "/v1/foo"},
+		{given: "/foo/{id}", expectedPath: "/foo/{id}", expectedParams: []string{"id"}},
+		{given: "/foo/{id}/files/{path...}", expectedPath: "/foo/{id}/files/{path}", expectedParams: []string{"id", "path"}},
+		{given: "/foo/{$}", expectedPath: "/foo/"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.given, func(t *testing.T) {
+			t.Parallel()
+
+			got, params := openAPIPath(tc.given)
+			assert.Equal(t, tc.expectedPath, got)
+
+			var names []string
+			for _, p := range params {
+				names = append(names, p.Name)
+			}
+			assert.Equal(t, tc.expectedParams, names)
+		})
+	}
+}
+
+// keys returns the keys of the map, in no particular order.
+func keys[V any](m map[string]V) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+func waitForServer(t *testing.T, url string) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		r, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		r.Body.Close()
+		return true
+	}, time.Second, 5*time.Millisecond)
+}
+
 func noopLogger() *slog.Logger {
 	return slog.New(slog.NewJSONHandler(io.Discard, nil))
 }
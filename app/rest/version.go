@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is a version of the API. Its resources are served under its name (e.g. /v1/foo),
+// with error handlers of their own, so the error contracts of a version evolve without breaking
+// the clients of the previous ones.
+type Version struct {
+	// Name is the path segment the resources of the version are served under (e.g. "v1").
+	Name string
+
+	// Resources are the resources of the version.
+	Resources []Resource
+
+	// Default serves the resources of the version without prefix as well,
+	// for the clients predating the versions of the API.
+	Default bool
+
+	// Deprecation is the time the version is deprecated from, if any.
+	// It is sent in the Deprecation header (RFC 9745) of the responses of the version.
+	Deprecation time.Time
+
+	// Sunset is the time the version stops being served, if any.
+	// It is sent in the Sunset header (RFC 8594) of the responses of the version.
+	Sunset time.Time
+}
+
+// Headers announcing the deprecation of a version.
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+)
+
+// WithVersion is an option to serve a version of the API.
+// At most one version may be the default one.
+func WithVersion(v Version) Option {
+	return func(app *App) {
+		app.versions = append(app.versions, v)
+	}
+}
+
+// validate checks that the version can be registered.
+func (v Version) validate() error {
+	if v.Name == "" || strings.ContainsAny(v.Name, "/ {}") {
+		return fmt.Errorf("name '%s' must be a single path segment", v.Name)
+	}
+
+	if len(v.Resources) == 0 {
+		return errors.New("at least one resource is required")
+	}
+
+	if !v.Deprecation.IsZero() && !v.Sunset.IsZero() && v.Sunset.Before(v.Deprecation) {
+		return errors.New("sunset must not be before deprecation")
+	}
+	return nil
+}
+
+// registerVersion registers the resources of the version under its path,
+// and under the root path too if it is the default version.
+func (app *App) registerVersion(mux *http.ServeMux, v Version) error {
+	if err := v.validate(); err != nil {
+		return err
+	}
+
+	prefixes := []string{"/" + v.Name}
+	if v.Default {
+		prefixes = append(prefixes, "")
+	}
+
+	for _, res := range v.Resources {
+		res.Middleware = append([]Middleware{v.deprecation}, res.Middleware...)
+
+		for _, prefix := range prefixes {
+			if err := app.registerResource(mux, prefix, res); err != nil {
+				return fmt.Errorf("could not register resource '%s': %w", res.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deprecation announces the deprecation and the sunset of the version, if any, in the response headers.
+func (v Version) deprecation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !v.Deprecation.IsZero() {
+			w.Header().Set(HeaderDeprecation, "@"+strconv.FormatInt(v.Deprecation.Unix(), 10))
+		}
+
+		if !v.Sunset.IsZero() {
+			w.Header().Set(HeaderSunset, v.Sunset.UTC().Format(http.TimeFormat))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
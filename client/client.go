@@ -0,0 +1,214 @@
+// client package is the Go client of the REST API. It decodes the problem details of the error responses
+// into errors matching the errors of the services, and retries the requests that may succeed when sent again.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+// maxErrorBodySize is the maximum size of the error responses read, so a misbehaving server
+// cannot make the client read an endless body.
+const maxErrorBodySize = 1 << 20
+
+// Retry configures the retries of the failed requests that may succeed when sent again.
+// The delay between two attempts doubles with every attempt, from Initial up to Max.
+type Retry struct {
+	MaxAttempts int
+	Initial     time.Duration
+	Max         time.Duration
+}
+
+// delay returns the delay before the attempt following the given one.
+func (r Retry) delay(attempt int) time.Duration {
+	d := r.Initial
+	for i := 1; i < attempt && d < r.Max; i++ {
+		d *= 2
+	}
+	return min(d, r.Max)
+}
+
+// DefaultRetry is the retry configuration of the clients created without the WithRetry option.
+var DefaultRetry = Retry{MaxAttempts: 3, Initial: 100 * time.Millisecond, Max: 2 * time.Second}
+
+// Option applies custom behavior to the client.
+type Option func(c *Client)
+
+// WithHTTPClient is an option to send the requests with the HTTP client instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRetry is an option to retry the failed requests as configured.
+// A single attempt disables the retries.
+func WithRetry(r Retry) Option {
+	return func(c *Client) {
+		c.retry = r
+	}
+}
+
+// Client calls the REST API.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retry      Retry
+}
+
+// New returns a client of the API served at the base URL, including the version
+// of the API to call, if any (e.g. https://api.resterrdemo.example/v2).
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("base URL '%s' is not an absolute URL", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetry,
+	}
+
+	for _, o := range opts {
+		o(&c)
+	}
+
+	if c.retry.MaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid retry: max attempts must be at least 1, got %d", c.retry.MaxAttempts)
+	}
+	return &c, nil
+}
+
+// GetFoo fetches the foo with the given ID.
+// Error responses are returned as *Error, wrapping the foo service error they report, if known.
+func (c *Client) GetFoo(ctx context.Context, id int64) (foo.Foo, error) {
+	var res foo.Foo
+	if err := c.get(ctx, "/foo/"+strconv.FormatInt(id, 10), &res); err != nil {
+		return foo.Foo{}, fmt.Errorf("could not get foo '%d': %w", id, err)
+	}
+	return res, nil
+}
+
+// GetBar fetches the bar with the given ID.
+// Error responses are returned as *Error, wrapping the bar service error they report, if known.
+func (c *Client) GetBar(ctx context.Context, id int64) (bar.Bar, error) {
+	var res bar.Bar
+	if err := c.get(ctx, "/bar/"+strconv.FormatInt(id, 10), &res); err != nil {
+		return bar.Bar{}, fmt.Errorf("could not get bar '%d': %w", id, err)
+	}
+	return res, nil
+}
+
+// get sends a GET request to the path, decoding the response into v, and retries it while it fails
+// with a retryable error. Every attempt carries the same request ID, taken from the context if any,
+// so they can be told apart from other requests in the logs of the API.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	id := requestid.Resolve(requestid.FromContext(ctx))
+
+	for attempt := 1; ; attempt++ {
+		err := c.do(ctx, path, id, v)
+		if err == nil || attempt >= c.retry.MaxAttempts || !retryable(ctx, err) {
+			return err
+		}
+
+		t := time.NewTimer(c.retry.delay(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w (gave up retrying: %w)", err, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// do sends a single GET request to the path, decoding the response into v.
+func (c *Client) do(ctx context.Context, path, id string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL.JoinPath(path).String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, "+problemtype.ContentType)
+	req.Header.Set(requestid.Header, id)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not decode response body: %w", err)
+	}
+	return nil
+}
+
+// decodeError decodes the error response, from its problem details if it has any.
+func decodeError(resp *http.Response) *Error {
+	apiErr := Error{
+		StatusCode: resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get(requestid.Header),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != problemtype.ContentType {
+		return &apiErr
+	}
+
+	var p problemtype.Details
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxErrorBodySize)).Decode(&p); err != nil {
+		return &apiErr
+	}
+
+	if p.Title != "" {
+		apiErr.Title = p.Title
+	}
+	apiErr.Type = p.Type
+	apiErr.Detail = p.Detail
+	apiErr.Extensions = p.Extensions
+	apiErr.err = problemtype.Errors[p.Type]
+	if apiErr.err == nil {
+		apiErr.err = requestErrors[p.Type]
+	}
+
+	if id, ok := p.Extensions["request-id"].(string); ok && apiErr.RequestID == "" {
+		apiErr.RequestID = id
+	}
+	return &apiErr
+}
+
+// retryable reports whether the failed request may succeed when sent again: the error response
+// is retryable, or the request could not be sent, as long as the caller did not give up on it.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
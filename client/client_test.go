@@ -0,0 +1,374 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alesr/resterrdemo/app/rest"
+	barhandler "github.com/alesr/resterrdemo/app/rest/handlers/bar"
+	foohandler "github.com/alesr/resterrdemo/app/rest/handlers/foo"
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+// testRetry retries without waiting, so the tests stay fast.
+var testRetry = Retry{MaxAttempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+
+type fooRepoMock struct {
+	fetchFunc func(ctx context.Context, id int64) (foo.Foo, error)
+}
+
+func (m *fooRepoMock) Fetch(ctx context.Context, id int64) (foo.Foo, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *fooRepoMock) List(context.Context) ([]foo.Foo, error) { return nil, nil }
+
+func (m *fooRepoMock) Create(_ context.Context, f foo.Foo) (foo.Foo, error) { return f, nil }
+
+func (m *fooRepoMock) Update(_ context.Context, f foo.Foo) (foo.Foo, error) { return f, nil }
+
+func (m *fooRepoMock) Delete(context.Context, int64) error { return nil }
+
+type barRepoMock struct {
+	fetchFunc func(ctx context.Context, id int64) (bar.Bar, error)
+}
+
+func (m *barRepoMock) Fetch(ctx context.Context, id int64) (bar.Bar, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *barRepoMock) List(context.Context) ([]bar.Bar, error) { return nil, nil }
+
+func (m *barRepoMock) Create(_ context.Context, b bar.Bar) (bar.Bar, error) { return b, nil }
+
+func (m *barRepoMock) Update(_ context.Context, b bar.Bar) (bar.Bar, error) { return b, nil }
+
+func (m *barRepoMock) Delete(context.Context, int64) error { return nil }
+
+// calls records the request IDs of the calls reaching a repository.
+type calls struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (c *calls) add(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ids = append(c.ids, requestid.FromContext(ctx))
+}
+
+func (c *calls) requestIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ids
+}
+
+// newTestServer serves the real REST application, with the version 1 of the API as the default one
+// and the version 2, on top of the repositories.
+func newTestServer(t *testing.T, fooRepo *fooRepoMock, barRepo *barRepoMock) *httptest.Server {
+	t.Helper()
+
+	fooErrHandler, err := problem.NewHandler(noopLogger, foohandler.ErrMap, problem.WithMatchers(foohandler.NewErrMatchers(foohandler.ErrMap)...))
+	require.NoError(t, err)
+
+	fooErrHandlerV2, err := problem.NewHandler(noopLogger, foohandler.ErrMapV2, problem.WithMatchers(foohandler.NewErrMatchers(foohandler.ErrMapV2)...))
+	require.NoError(t, err)
+
+	barErrHandler, err := problem.NewHandler(noopLogger, barhandler.ErrMap, problem.WithMatchers(barhandler.NewErrMatchers(barhandler.ErrMap)...))
+	require.NoError(t, err)
+
+	fooSvc := foo.New(fooRepo)
+
+	fooHandler, err := foohandler.NewHandler(noopLogger, fooSvc, fooErrHandler)
+	require.NoError(t, err)
+
+	fooHandlerV2, err := foohandler.NewHandler(noopLogger, fooSvc, fooErrHandlerV2)
+	require.NoError(t, err)
+
+	barHandler, err := barhandler.NewHandler(noopLogger, bar.New(barRepo), barErrHandler)
+	require.NoError(t, err)
+
+	app, err := rest.NewApp(
+		noopLogger,
+		config.Server{Addr: ":0"},
+		rest.WithVersion(rest.Version{
+			Name: "v1",
+			Resources: []rest.Resource{
+				{Name: "foo", Routes: rest.CRUD(fooHandler), ErrorHandler: fooErrHandler},
+				{Name: "bar", Routes: rest.CRUD(barHandler), ErrorHandler: barErrHandler},
+			},
+			Default: true,
+		}),
+		rest.WithVersion(rest.Version{
+			Name: "v2",
+			Resources: []rest.Resource{
+				{Name: "foo", Routes: rest.CRUD(fooHandlerV2), ErrorHandler: fooErrHandlerV2},
+				{Name: "bar", Routes: rest.CRUD(barHandler), ErrorHandler: barErrHandler},
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(app)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_GetFoo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		version            string
+		failures           int
+		repoErr            error
+		expectedFoo        foo.Foo
+		expectedError      error
+		expectedStatusCode int
+		expectedDetail     string
+		expectedCalls      int
+	}{
+		{
+			name:          "found",
+			expectedFoo:   foo.Foo{ID: 1, Name: "foo"},
+			expectedCalls: 1,
+		},
+		{
+			name:               "not found is not retried",
+			failures:           testRetry.MaxAttempts,
+			repoErr:            foo.ErrFooNotFound.With("id", "1"),
+			expectedError:      foo.ErrFooNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedDetail:     "foo '1' not found",
+			expectedCalls:      1,
+		},
+		{
+			name:          "retryable error retried until success",
+			failures:      testRetry.MaxAttempts - 1,
+			repoErr:       assert.AnError,
+			expectedFoo:   foo.Foo{ID: 1, Name: "foo"},
+			expectedCalls: testRetry.MaxAttempts,
+		},
+		{
+			name:               "retryable error retried until exhaustion",
+			failures:           testRetry.MaxAttempts,
+			repoErr:            assert.AnError,
+			expectedError:      foo.ErrGetFaleid,
+			expectedStatusCode: http.StatusTeapot,
+			expectedDetail:     "could not perform the get foo operation",
+			expectedCalls:      testRetry.MaxAttempts,
+		},
+		{
+			name:               "timeout in the API is not the caller's",
+			failures:           testRetry.MaxAttempts,
+			repoErr:            context.DeadlineExceeded,
+			expectedError:      ErrRequestTimeout,
+			expectedStatusCode: http.StatusGatewayTimeout,
+			expectedDetail:     "the request timed out",
+			expectedCalls:      testRetry.MaxAttempts,
+		},
+		{
+			name:               "version 2 error map",
+			version:            "/v2",
+			failures:           testRetry.MaxAttempts,
+			repoErr:            assert.AnError,
+			expectedError:      foo.ErrGetFaleid,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedDetail:     "could not perform the get foo operation",
+			expectedCalls:      testRetry.MaxAttempts,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var c calls
+			fooRepo := fooRepoMock{
+				fetchFunc: func(ctx context.Context, id int64) (foo.Foo, error) {
+					c.add(ctx)
+					if len(c.requestIDs()) <= tc.failures {
+						return foo.Foo{}, tc.repoErr
+					}
+					return foo.Foo{ID: id, Name: "foo"}, nil
+				},
+			}
+			srv := newTestServer(t, &fooRepo, &barRepoMock{})
+
+			cl, err := New(srv.URL+tc.version, WithHTTPClient(srv.Client()), WithRetry(testRetry))
+			require.NoError(t, err)
+
+			ctx := requestid.NewContext(context.TODO(), "client-request")
+			got, err := cl.GetFoo(ctx, 1)
+
+			assert.Len(t, c.requestIDs(), tc.expectedCalls)
+			for _, id := range c.requestIDs() {
+				assert.Equal(t, "client-request", id)
+			}
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedFoo, got)
+				return
+			}
+
+			require.ErrorIs(t, err, tc.expectedError)
+			assert.NotErrorIs(t, err, context.DeadlineExceeded)
+
+			var apiErr *Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.expectedStatusCode, apiErr.StatusCode)
+			assert.Equal(t, tc.expectedDetail, apiErr.Detail)
+			assert.Equal(t, "client-request", apiErr.RequestID)
+		})
+	}
+}
+
+func TestClient_GetBar(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		repoErr            error
+		expectedBar        bar.Bar
+		expectedStatusCode int
+	}{
+		{
+			name:        "found",
+			expectedBar: bar.Bar{ID: 1, Name: "bar"},
+		},
+		{
+			name:               "hidden error",
+			repoErr:            bar.ErrBarStorageUnavailable,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var c calls
+			barRepo := barRepoMock{
+				fetchFunc: func(ctx context.Context, id int64) (bar.Bar, error) {
+					c.add(ctx)
+					if tc.repoErr != nil {
+						return bar.Bar{}, tc.repoErr
+					}
+					return bar.Bar{ID: id, Name: "bar"}, nil
+				},
+			}
+			srv := newTestServer(t, &fooRepoMock{}, &barRepo)
+
+			cl, err := New(srv.URL, WithHTTPClient(srv.Client()), WithRetry(testRetry))
+			require.NoError(t, err)
+
+			got, err := cl.GetBar(context.TODO(), 1)
+			assert.Len(t, c.requestIDs(), 1)
+
+			if tc.expectedStatusCode == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedBar, got)
+				return
+			}
+
+			var apiErr *Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.expectedStatusCode, apiErr.StatusCode)
+			assert.NotEmpty(t, apiErr.RequestID)
+
+			// The bar service hides the unavailability of the bar, so the error matches no service error,
+			// and internal server errors are not retried.
+			assert.NotErrorIs(t, err, bar.ErrBarUnavailable)
+			assert.NoError(t, errors.Unwrap(apiErr))
+			assert.False(t, apiErr.Retryable())
+		})
+	}
+}
+
+func TestClient_Get_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	fooRepo := fooRepoMock{
+		fetchFunc: func(context.Context, int64) (foo.Foo, error) {
+			cancel()
+			return foo.Foo{}, assert.AnError
+		},
+	}
+	srv := newTestServer(t, &fooRepo, &barRepoMock{})
+
+	cl, err := New(srv.URL, WithHTTPClient(srv.Client()), WithRetry(Retry{MaxAttempts: 3, Initial: time.Minute, Max: time.Minute}))
+	require.NoError(t, err)
+
+	_, err = cl.GetFoo(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		baseURL       string
+		opts          []Option
+		expectedError string
+	}{
+		{
+			name:    "valid",
+			baseURL: "https://api.resterrdemo.example/v2/",
+		},
+		{
+			name:          "relative URL",
+			baseURL:       "/v2",
+			expectedError: "base URL '/v2' is not an absolute URL",
+		},
+		{
+			name:          "no attempt",
+			baseURL:       "https://api.resterrdemo.example",
+			opts:          []Option{WithRetry(Retry{})},
+			expectedError: "invalid retry: max attempts must be at least 1, got 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := New(tc.baseURL, tc.opts...)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	t.Parallel()
+
+	err := &Error{StatusCode: http.StatusNotFound, Title: "Foo Not Found", Detail: "foo '1' not found", RequestID: "abc"}
+	assert.Equal(t, "resterrdemo: 404 Foo Not Found: foo '1' not found (request ID 'abc')", err.Error())
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/alesr/resterrdemo/problemtype"
+	"github.com/alesr/resterrdemo/service/domainerr"
+)
+
+var (
+	// ErrRequestCanceled is the error of the responses reporting that the API gave up on the request
+	// because it was canceled while being served.
+	ErrRequestCanceled = errors.New("request canceled by the API")
+
+	// ErrRequestTimeout is the error of the responses reporting that the API gave up on the request
+	// because serving it took too long.
+	ErrRequestTimeout = errors.New("request timed out in the API")
+)
+
+// requestErrors maps the problem types of the requests the API gave up on to the errors of the client.
+// They are not decoded into the context errors, which would be mistaken for the ones of the caller's context.
+var requestErrors = map[string]error{
+	problemtype.RequestCanceled: ErrRequestCanceled,
+	problemtype.RequestTimeout:  ErrRequestTimeout,
+}
+
+// Error is an error response of the API, decoded from its problem details.
+//
+// When the problem type is one the API maps a known error to, the error wraps that error,
+// so callers can match the errors of the services with errors.Is (e.g. foo.ErrFooNotFound),
+// instead of parsing the messages. The requests the API gave up on wrap ErrRequestCanceled or ErrRequestTimeout.
+type Error struct {
+	// StatusCode is the status of the response.
+	StatusCode int
+
+	// Type, Title and Detail are the members of the problem details.
+	// Title defaults to the text of the status when the response is not a problem.
+	Type   string
+	Title  string
+	Detail string
+
+	// RequestID identifies the request in the logs of the API.
+	RequestID string
+
+	// Extensions are the other members of the problem, such as the invalid parameters of a validation error.
+	Extensions map[string]any
+
+	// err is the error the problem type is mapped from, if known.
+	err error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("resterrdemo: %d %s", e.StatusCode, e.Title)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+
+	if e.RequestID != "" {
+		msg += " (request ID '" + e.RequestID + "')"
+	}
+	return msg
+}
+
+// Unwrap returns the error the problem type is mapped from by the API, if known.
+func (e *Error) Unwrap() error { return e.err }
+
+// Retryable reports whether sending the same request again may succeed: either the error
+// is declared retryable by its service, or the status tells that the API is temporarily unable to serve it.
+func (e *Error) Retryable() bool {
+	var domainErr *domainerr.Error
+	if errors.As(e.err, &domainErr) {
+		return domainErr.Retryable
+	}
+	return slices.Contains(retryableStatuses, e.StatusCode)
+}
+
+// retryableStatuses are the statuses of the responses worth retrying, when the error is not known.
+var retryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	file := writeFile(t, `{"addr": ":7070", "db-dsn": "postgres://file", "log-format": "json", "write-timeout": "20s", "db-max-open-conns": 20}`)
+
+	testCases := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected func(c *Config)
+	}{
+		{
+			name:     "defaults",
+			expected: func(c *Config) {},
+		},
+		{
+			name: "file",
+			args: []string{"-config", file},
+			expected: func(c *Config) {
+				c.Server.Addr = ":7070"
+				c.Server.WriteTimeout = 20 * time.Second
+				c.Database.DSN = "postgres://file"
+				c.Database.MaxOpenConns = 20
+				c.Log.Format = FormatJSON
+			},
+		},
+		{
+			name: "file from environment",
+			env:  map[string]string{"RESTERRDEMO_CONFIG": file},
+			expected: func(c *Config) {
+				c.Server.Addr = ":7070"
+				c.Server.WriteTimeout = 20 * time.Second
+				c.Database.DSN = "postgres://file"
+				c.Database.MaxOpenConns = 20
+				c.Log.Format = FormatJSON
+			},
+		},
+		{
+			name: "environment takes precedence over file",
+			args: []string{"-config", file},
+			env: map[string]string{
+				"RESTERRDEMO_ADDR":              ":6060",
+				"RESTERRDEMO_DB_QUERY_TIMEOUT":  "1s",
+				"RESTERRDEMO_LOG_LEVEL":         "debug",
+				"RESTERRDEMO_DB_MAX_OPEN_CONNS": "0",
+				"RESTERRDEMO_GRPC_ADDR":         ":6061",
+			},
+			expected: func(c *Config) {
+				c.Server.Addr = ":6060"
+				c.GRPC.Addr = ":6061"
+				c.Server.WriteTimeout = 20 * time.Second
+				c.Database.DSN = "postgres://file"
+				c.Database.QueryTimeout = time.Second
+				c.Database.MaxOpenConns = 0
+				c.Log.Format = FormatJSON
+				c.Log.Level = slog.LevelDebug
+			},
+		},
+		{
+			name: "flags take precedence over environment",
+			args: []string{"-config", file, "-addr", ":5050", "-shutdown-grace-period", "30s", "-trace-exporter", "stdout"},
+			env:  map[string]string{"RESTERRDEMO_ADDR": ":6060", "RESTERRDEMO_TRACE_EXPORTER": "none"},
+			expected: func(c *Config) {
+				c.Server.Addr = ":5050"
+				c.Trace.Exporter = ExporterStdout
+				c.Server.WriteTimeout = 20 * time.Second
+				c.Server.ShutdownGracePeriod = 30 * time.Second
+				c.Database.DSN = "postgres://file"
+				c.Database.MaxOpenConns = 20
+				c.Log.Format = FormatJSON
+			},
+		},
+		{
+			name: "command",
+			args: []string{"-log-level", "debug", "foo", "get", "1"},
+			expected: func(c *Config) {
+				c.Log.Level = slog.LevelDebug
+				c.Command = []string{"foo", "get", "1"}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Load(tc.args, func(k string) string { return tc.env[k] })
+			require.NoError(t, err)
+
+			expected := Default()
+			tc.expected(&expected)
+			assert.Equal(t, expected, got)
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	file := writeFile(t, `{"addr": "localhost", "timeout": "1s"}`)
+	typedFile := writeFile(t, `{"grpc-addr": [":9090"], "log-format": true, "db-max-open-conns": 2.5}`)
+
+	testCases := []struct {
+		name           string
+		args           []string
+		env            map[string]string
+		expectedErrors []string
+	}{
+		{
+			name: "every problem is listed",
+			args: []string{"-config", file, "-read-timeout", "soon", "-log-format", "xml", "-trace-exporter", "jaeger"},
+			env: map[string]string{
+				"RESTERRDEMO_LOG_LEVEL":         "loud",
+				"RESTERRDEMO_DB_MAX_OPEN_CONNS": "-1",
+				"RESTERRDEMO_IDLE_TIMEOUT":      "0s",
+				"RESTERRDEMO_GRPC_ADDR":         "grpc",
+			},
+			expectedErrors: []string{
+				"invalid value 'soon' from flag -read-timeout: not a duration",
+				"invalid value 'loud' from environment variable RESTERRDEMO_LOG_LEVEL",
+				"unknown key 'timeout' in file '" + file + "'",
+				"server address 'localhost' is not a valid host:port address",
+				"gRPC server address 'grpc' is not a valid host:port address",
+				"idle timeout '0s' must be positive",
+				"database max open connections '-1' must not be negative",
+				"log format 'xml' must be 'text' or 'json'",
+				"trace exporter 'jaeger' must be 'none' or 'stdout'",
+			},
+		},
+		{
+			name:           "missing file",
+			args:           []string{"-config", filepath.Join(t.TempDir(), "missing.json")},
+			expectedErrors: []string{"could not read config file"},
+		},
+		{
+			name:           "same addresses",
+			args:           []string{"-addr", ":7070", "-grpc-addr", ":7070"},
+			expectedErrors: []string{"gRPC server address ':7070' must differ from the server address"},
+		},
+		{
+			name:           "empty DSN",
+			args:           []string{"-db-dsn", ""},
+			expectedErrors: []string{"database DSN is required"},
+		},
+		{
+			name: "file values of other types",
+			args: []string{"-config", typedFile},
+			expectedErrors: []string{
+				`invalid value [":9090"] for key 'grpc-addr' in file '` + typedFile + `': not a string, number or boolean`,
+				"log format 'true' must be 'text' or 'json'",
+				"invalid value '2.5' from file key 'db-max-open-conns'",
+			},
+		},
+		{
+			name:           "unknown flag",
+			args:           []string{"-port", "8080"},
+			expectedErrors: []string{"could not parse flags"},
+		},
+		{
+			name: "unknown flag with other problems",
+			args: []string{"-log-format", "xml", "-port", "8080"},
+			env:  map[string]string{"RESTERRDEMO_LOG_LEVEL": "loud"},
+			expectedErrors: []string{
+				"could not parse flags: flag provided but not defined: -port",
+				"invalid value 'loud' from environment variable RESTERRDEMO_LOG_LEVEL",
+				"log format 'xml' must be 'text' or 'json'",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(tc.args, func(k string) string { return tc.env[k] })
+			require.Error(t, err)
+
+			for _, expected := range tc.expectedErrors {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestLoad_Help(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load([]string{"-h"}, func(string) string { return "" })
+	assert.ErrorIs(t, err, flag.ErrHelp)
+
+	var usage strings.Builder
+	Usage(&usage)
+	assert.Contains(t, usage.String(), "RESTERRDEMO_DB_DSN")
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
@@ -0,0 +1,671 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alesr/resterrdemo/app/cli"
+	grpcapp "github.com/alesr/resterrdemo/app/grpc"
+	"github.com/alesr/resterrdemo/app/grpc/pb"
+	"github.com/alesr/resterrdemo/app/queue"
+	"github.com/alesr/resterrdemo/app/rest"
+	barhandler "github.com/alesr/resterrdemo/app/rest/handlers/bar"
+	foohandler "github.com/alesr/resterrdemo/app/rest/handlers/foo"
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/problemtype"
+	barrepo "github.com/alesr/resterrdemo/repository/bar"
+	foorepo "github.com/alesr/resterrdemo/repository/foo"
+	"github.com/alesr/resterrdemo/requestid"
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/alesr/resterrdemo/service/foo"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var noopLogger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+// errConnRefused is the error of the driver when the database cannot be reached.
+var errConnRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+// TestErrorPropagation wires the real repositories, services and handlers on top of
+// a database stand-in, and checks how each driver outcome surfaces on the REST API.
+func TestErrorPropagation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		query          string
+		expect         func(query *sqlmock.ExpectedQuery)
+		expectedStatus int
+		expectedBody   *problem.Problem
+	}{
+		{
+			name:  "foo found",
+			path:  "/foo/1",
+			query: "SELECT id, name FROM foo",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "name"))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "foo not found is mapped",
+			path:  "/foo/1",
+			query: "SELECT id, name FROM foo",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody: &problem.Problem{
+				Status: http.StatusNotFound,
+				Detail: "foo '1' not found",
+			},
+		},
+		{
+			name:  "foo storage unavailable is mapped",
+			path:  "/foo/1",
+			query: "SELECT id, name FROM foo",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(errConnRefused)
+			},
+			expectedStatus: http.StatusTeapot,
+			expectedBody: &problem.Problem{
+				Status: http.StatusTeapot,
+				Detail: "could not perform the get foo operation",
+			},
+		},
+		{
+			name:  "foo query timeout is storage unavailable",
+			path:  "/foo/1",
+			query: "SELECT id, name FROM foo",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedStatus: http.StatusTeapot,
+			expectedBody: &problem.Problem{
+				Status: http.StatusTeapot,
+				Detail: "could not perform the get foo operation",
+			},
+		},
+		{
+			name:  "bar found",
+			path:  "/bar/1",
+			query: "SELECT id, name FROM bar",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "name"))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "bar not found is mapped",
+			path:  "/bar/1",
+			query: "SELECT id, name FROM bar",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody: &problem.Problem{
+				Status: http.StatusNotFound,
+				Detail: "bar not found",
+			},
+		},
+		{
+			name:  "bar storage unavailable is hidden",
+			path:  "/bar/1",
+			query: "SELECT id, name FROM bar",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(errConnRefused)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: &problem.Problem{
+				Status: http.StatusInternalServerError,
+				Detail: "something went wrong",
+			},
+		},
+		{
+			name:   "bar name taken is mapped",
+			method: http.MethodPost,
+			path:   "/bar",
+			body:   `{"name":"taken"}`,
+			query:  "INSERT INTO bar",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody: &problem.Problem{
+				Status: http.StatusConflict,
+				Detail: "a bar with this name already exists",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(tc.query))
+
+			app := newTestApp(t, noopLogger, nil, foorepo.NewPostgres(db, config.Database{}), barrepo.NewPostgres(db, config.Database{}))
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			req := httptest.NewRequest(method, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+
+			app.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expectedBody == nil {
+				return
+			}
+
+			var got problem.Problem
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+
+			assert.Equal(t, tc.expectedBody.Status, got.Status)
+			assert.Equal(t, tc.expectedBody.Detail, got.Detail)
+			assert.Equal(t, tc.path, got.Instance)
+			assert.Equal(t, w.Result().Header.Get(requestid.Header), got.Extensions["request-id"])
+		})
+	}
+}
+
+// TestRequestIDCorrelation checks that the request ID sent by the client is found
+// in the error response and in the log record of the error handler.
+func TestRequestIDCorrelation(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, name FROM foo").WillReturnError(sql.ErrNoRows)
+
+	var logs bytes.Buffer
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(&logs, nil)))
+
+	app := newTestApp(t, logger, nil, foorepo.NewPostgres(db, config.Database{}), barrepo.NewPostgres(db, config.Database{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/1", nil)
+	req.Header.Set(requestid.Header, "req-1")
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	assert.Equal(t, "req-1", w.Result().Header.Get(requestid.Header))
+
+	var got problem.Problem
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+	assert.Equal(t, "req-1", got.Extensions["request-id"])
+
+	var record struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request-id"`
+	}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+
+	assert.Equal(t, "Handling mapped error.", record.Msg)
+	assert.Equal(t, "req-1", record.RequestID)
+}
+
+// TestErrorMetrics checks that the errors handled for each resource are counted by the sentinel they were
+// translated from, and that the hidden ones are counted as unmapped.
+func TestErrorMetrics(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, name FROM foo").WillReturnError(errors.New("syntax error"))
+	mock.ExpectQuery("SELECT id, name FROM foo").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT id, name FROM bar").WillReturnError(errConnRefused)
+
+	app := newTestApp(t, noopLogger, nil, foorepo.NewPostgres(db, config.Database{}), barrepo.NewPostgres(db, config.Database{}))
+
+	for _, path := range []string{"/foo/1", "/foo/2", "/bar/1"} {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, w.Body.String(), `problem_errors_total{error="foo.get_failed",resource="foo-v1"} 1`)
+	assert.Contains(t, w.Body.String(), `problem_errors_total{error="foo.not_found",resource="foo-v1"} 1`)
+	assert.Contains(t, w.Body.String(), `problem_errors_total{error="unmapped",resource="bar"} 1`)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/foo/{id}",status="418"} 1`)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/bar/{id}",status="500"} 1`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTracing checks that a request is traced across the transport, service and storage layers,
+// continuing the trace of the client, and that the error is recorded with the sentinel it was translated from.
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, name FROM foo").WillReturnError(errors.New("syntax error"))
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	app := newTestApp(t, noopLogger, tp, foorepo.NewPostgres(db, config.Database{}, foorepo.WithTracerProvider(tp)), barrepo.NewPostgres(db, config.Database{}))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+
+	// Spans are exported as they end, from the innermost one.
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	repoSpan, svcSpan, serverSpan := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "foo.Postgresql.Fetch", repoSpan.Name)
+	assert.Equal(t, "foo.Service.Fetch", svcSpan.Name)
+	assert.Equal(t, "GET /foo/{id}", serverSpan.Name)
+
+	for _, span := range spans {
+		assert.Equal(t, traceID, span.SpanContext.TraceID().String())
+	}
+	assert.Equal(t, svcSpan.SpanContext.SpanID(), repoSpan.Parent.SpanID())
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), svcSpan.Parent.SpanID())
+
+	assert.Equal(t, otelcodes.Error, repoSpan.Status.Code)
+	assert.Equal(t, otelcodes.Error, svcSpan.Status.Code)
+
+	// The teapot is a client error: it is recorded on the request span without failing it.
+	assert.Equal(t, otelcodes.Unset, serverSpan.Status.Code)
+	assert.Contains(t, serverSpan.Attributes, attribute.String("error.type", "foo.get_failed"))
+	assert.Contains(t, serverSpan.Attributes, attribute.Int("http.response.status_code", http.StatusTeapot))
+
+	require.Len(t, serverSpan.Events, 1)
+	assert.Contains(t, serverSpan.Events[0].Attributes, attribute.Int("http.response.status_code", http.StatusTeapot))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestVersions checks that each version of the API translates the errors with its own error map,
+// and that the deprecated version announces its sunset.
+func TestVersions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path               string
+		expectedStatus     int
+		expectedDeprecated bool
+	}{
+		{path: "/foo/1", expectedStatus: http.StatusTeapot, expectedDeprecated: true},
+		{path: "/v1/foo/1", expectedStatus: http.StatusTeapot, expectedDeprecated: true},
+		{path: "/v2/foo/1", expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT id, name FROM foo").WillReturnError(errors.New("syntax error"))
+
+			app := newTestApp(t, noopLogger, nil, foorepo.NewPostgres(db, config.Database{}), barrepo.NewPostgres(db, config.Database{}))
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+
+			var got problem.Problem
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&got))
+			assert.Equal(t, "https://api.resterrdemo.example/problems/foo/get-failed", got.Type)
+			assert.Equal(t, tc.expectedStatus, got.Status)
+
+			if tc.expectedDeprecated {
+				assert.NotEmpty(t, w.Result().Header.Get(rest.HeaderDeprecation))
+				assert.NotEmpty(t, w.Result().Header.Get(rest.HeaderSunset))
+			} else {
+				assert.Empty(t, w.Result().Header.Get(rest.HeaderDeprecation))
+				assert.Empty(t, w.Result().Header.Get(rest.HeaderSunset))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestOpenAPI checks that each route of the OpenAPI document describes the error responses
+// of the errors it fails with, in the version it belongs to, including the members added by the matchers.
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	app := newTestApp(t, noopLogger, nil, foorepo.NewPostgres(db, config.Database{}), barrepo.NewPostgres(db, config.Database{}))
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	type response struct {
+		Content map[string]struct {
+			Examples map[string]struct{ Value problem.Problem }
+		}
+	}
+
+	var doc struct {
+		Paths map[string]map[string]struct{ Responses map[string]response }
+	}
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&doc))
+
+	statuses := func(p, method string) []string {
+		var got []string
+		for code := range doc.Paths[p][method].Responses {
+			got = append(got, code)
+		}
+		return got
+	}
+
+	example := func(p, method, code, name string) problem.Problem {
+		return doc.Paths[p][method].Responses[code].Content[problem.ContentType].Examples[name].Value
+	}
+
+	assert.ElementsMatch(t, []string{"200", "400", "404", "499", "503", "504", "500"}, statuses("/v2/foo/{id}", "get"))
+	assert.ElementsMatch(t, []string{"200", "400", "404", "418", "499", "504", "500"}, statuses("/v1/foo/{id}", "get"))
+	assert.ElementsMatch(t, []string{"201", "400", "422", "499", "503", "504", "500"}, statuses("/v2/foo", "post"))
+	assert.ElementsMatch(t, []string{"204", "400", "404", "499", "504", "500"}, statuses("/v2/bar/{id}", "delete"))
+
+	assert.Equal(t, map[string]any{"id": "42"}, example("/v2/foo/{id}", "get", "404", "not-found").Extensions)
+	assert.Equal(t, map[string]any{
+		"invalid-params": []any{map[string]any{"name": "name", "reason": "must not be empty"}},
+	}, example("/v2/foo", "post", "422", "invalid").Extensions)
+}
+
+// TestGRPCErrorPropagation serves the same services over gRPC, and checks that the driver outcomes
+// surface as the statuses of the gRPC error maps, independently of the REST error maps.
+func TestGRPCErrorPropagation(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, name FROM foo").WillReturnError(errors.New("syntax error"))
+	mock.ExpectQuery("SELECT id, name FROM bar").WillReturnError(errConnRefused)
+
+	fooErrHandler, err := grpcapp.NewErrorHandler(noopLogger, grpcapp.FooErrMap)
+	require.NoError(t, err)
+
+	barErrHandler, err := grpcapp.NewErrorHandler(noopLogger, grpcapp.BarErrMap)
+	require.NoError(t, err)
+
+	server := grpcapp.NewServer(
+		noopLogger,
+		config.GRPC{},
+		foo.New(foorepo.NewPostgres(db, config.Database{})), fooErrHandler,
+		bar.New(barrepo.NewPostgres(db, config.Database{})), barErrHandler,
+	)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The failures to get a foo are a teapot on the REST API, but the service being unavailable over gRPC.
+	_, err = pb.NewFooServiceClient(conn).GetFoo(context.TODO(), &pb.GetFooRequest{Id: 1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "could not perform the get foo operation", status.Convert(err).Message())
+
+	// The bar unavailability is hidden from the clients of both transports.
+	_, err = pb.NewBarServiceClient(conn).GetBar(context.TODO(), &pb.GetBarRequest{Id: 1})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "something went wrong", status.Convert(err).Message())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestErrMaps_MatchServiceErrors checks the error maps of the transports other than the REST API
+// against the errors declared by the services, as the application does at startup.
+func TestErrMaps_MatchServiceErrors(t *testing.T) {
+	t.Parallel()
+
+	grpcFoo, err := grpcapp.NewErrorHandler(noopLogger, grpcapp.FooErrMap)
+	require.NoError(t, err)
+
+	grpcBar, err := grpcapp.NewErrorHandler(noopLogger, grpcapp.BarErrMap)
+	require.NoError(t, err)
+
+	cliFoo, err := cli.NewErrorHandler(noopLogger, cli.FooErrMap)
+	require.NoError(t, err)
+
+	cliBar, err := cli.NewErrorHandler(noopLogger, cli.BarErrMap)
+	require.NoError(t, err)
+
+	queueFoo, err := queue.NewErrorHandler(noopLogger, queue.FooErrMap)
+	require.NoError(t, err)
+
+	queueBar, err := queue.NewErrorHandler(noopLogger, queue.BarErrMap)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		isMapped func(err error) bool
+		registry domainerr.Registry
+	}{
+		{name: "grpc foo", isMapped: grpcFoo.IsMapped, registry: foo.Errors},
+		{name: "grpc bar", isMapped: grpcBar.IsMapped, registry: bar.Errors},
+		{name: "cli foo", isMapped: cliFoo.IsMapped, registry: foo.Errors},
+		{name: "cli bar", isMapped: cliBar.IsMapped, registry: bar.Errors},
+
+		// Hidden errors may be mapped in the decision tables, the queue having no client to keep them from.
+		{name: "queue foo", isMapped: queueFoo.IsMapped, registry: publicErrors(foo.Errors)},
+		{name: "queue bar", isMapped: queueBar.IsMapped, registry: publicErrors(bar.Errors)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.NoError(t, tc.registry.Validate(tc.isMapped))
+		})
+	}
+}
+
+// publicErrors returns the public errors of the registry.
+func publicErrors(r domainerr.Registry) domainerr.Registry {
+	var public domainerr.Registry
+	for _, s := range r {
+		if s.Visibility == domainerr.Public {
+			public = append(public, s)
+		}
+	}
+	return public
+}
+
+// TestProblemTypes_MatchErrMaps checks that the errors the client decodes the problem types into
+// are the errors the problem types are sent for, in every version of the API.
+func TestProblemTypes_MatchErrMaps(t *testing.T) {
+	t.Parallel()
+
+	sent := make(map[string]bool)
+	for _, m := range []problem.Map{foohandler.ErrMap, foohandler.ErrMapV2, barhandler.ErrMap} {
+		for _, e := range m {
+			sent[e.Value.Type] = true
+
+			if err, ok := problemtype.Errors[e.Value.Type]; ok {
+				assert.Equal(t, e.Err, err, "problem type '%s'", e.Value.Type)
+			}
+		}
+	}
+
+	for typ := range problemtype.Errors {
+		assert.True(t, sent[typ], "problem type '%s' is not sent by any error map", typ)
+	}
+}
+
+// TestReadiness checks that the readiness endpoint reports the storage that cannot be reached,
+// without leaking the driver errors.
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	fooDB, fooMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer fooDB.Close()
+
+	barDB, barMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer barDB.Close()
+
+	fooMock.ExpectPing().WillReturnError(errConnRefused)
+	barMock.ExpectPing()
+
+	app := newTestApp(t, noopLogger, nil, foorepo.NewPostgres(fooDB, config.Database{}), barrepo.NewPostgres(barDB, config.Database{}))
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+	assert.JSONEq(t, `{
+		"status": "not ready",
+		"dependencies": {
+			"foo-storage": {
+				"status": "unavailable",
+				"problem": {"type": "about:blank", "status": 500, "title": "Internal Server Error", "detail": "something went wrong"}
+			},
+			"bar-storage": {"status": "available"}
+		}
+	}`, w.Body.String())
+
+	assert.NoError(t, fooMock.ExpectationsWereMet())
+	assert.NoError(t, barMock.ExpectationsWereMet())
+}
+
+func newTestApp(t *testing.T, logger *slog.Logger, tp trace.TracerProvider, fooRepo *foorepo.Postgresql, barRepo *barrepo.Postgresql) *rest.App {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+
+	fooErrHandler, err := problem.NewHandler(
+		logger,
+		foohandler.ErrMap,
+		problem.WithMatchers(foohandler.NewErrMatchers(foohandler.ErrMap)...),
+		problem.WithKindDefaults(foo.Errors),
+		problem.WithJoinedErrors(problem.HighestStatus),
+		problem.WithMetrics(registry, "foo-v1"),
+	)
+	require.NoError(t, err)
+
+	fooSvc := foo.New(fooRepo, foo.WithTracerProvider(tp))
+
+	fooHandler, err := foohandler.NewHandler(logger, fooSvc, fooErrHandler)
+	require.NoError(t, err)
+
+	fooErrHandlerV2, err := problem.NewHandler(
+		logger,
+		foohandler.ErrMapV2,
+		problem.WithMatchers(foohandler.NewErrMatchers(foohandler.ErrMapV2)...),
+		problem.WithKindDefaults(foo.Errors),
+		problem.WithJoinedErrors(problem.HighestStatus),
+		problem.WithMetrics(registry, "foo-v2"),
+	)
+	require.NoError(t, err)
+
+	fooHandlerV2, err := foohandler.NewHandler(logger, fooSvc, fooErrHandlerV2)
+	require.NoError(t, err)
+
+	barErrHandler, err := problem.NewHandler(
+		logger,
+		barhandler.ErrMap,
+		problem.WithMatchers(barhandler.NewErrMatchers(barhandler.ErrMap)...),
+		problem.WithKindDefaults(bar.Errors),
+		problem.WithJoinedErrors(problem.HighestStatus),
+		problem.WithMetrics(registry, "bar"),
+	)
+	require.NoError(t, err)
+
+	barHandler, err := barhandler.NewHandler(logger, bar.New(barRepo, bar.WithTracerProvider(tp)), barErrHandler)
+	require.NoError(t, err)
+
+	app, err := rest.NewApp(
+		logger,
+		config.Server{Addr: ":0", ReadinessTimeout: time.Second},
+		rest.WithVersion(rest.Version{
+			Name: "v1",
+			Resources: []rest.Resource{
+				{Name: "foo", Routes: rest.CRUD(fooHandler), ErrorHandler: fooErrHandler},
+				{Name: "bar", Routes: rest.CRUD(barHandler), ErrorHandler: barErrHandler},
+			},
+			Default:     true,
+			Deprecation: v1Deprecation,
+			Sunset:      v1Sunset,
+		}),
+		rest.WithVersion(rest.Version{
+			Name: "v2",
+			Resources: []rest.Resource{
+				{Name: "foo", Routes: rest.CRUD(fooHandlerV2), ErrorHandler: fooErrHandlerV2},
+				{Name: "bar", Routes: rest.CRUD(barHandler), ErrorHandler: barErrHandler},
+			},
+		}),
+		rest.WithMetrics(registry),
+		rest.WithTracerProvider(tp),
+		rest.WithDependency("foo-storage", fooRepo, fooErrHandler),
+		rest.WithDependency("bar-storage", barRepo, barErrHandler),
+	)
+	require.NoError(t, err)
+	return app
+}
@@ -2,83 +2,370 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/alesr/resterr"
+	"github.com/alesr/resterrdemo/app/cli"
+	grpcapp "github.com/alesr/resterrdemo/app/grpc"
 	"github.com/alesr/resterrdemo/app/rest"
 	barhandler "github.com/alesr/resterrdemo/app/rest/handlers/bar"
 	foohandler "github.com/alesr/resterrdemo/app/rest/handlers/foo"
+	"github.com/alesr/resterrdemo/app/rest/problem"
+	"github.com/alesr/resterrdemo/config"
 	barrepo "github.com/alesr/resterrdemo/repository/bar"
 	foorepo "github.com/alesr/resterrdemo/repository/foo"
+	"github.com/alesr/resterrdemo/repository/postgres"
+	"github.com/alesr/resterrdemo/requestid"
 	"github.com/alesr/resterrdemo/service/bar"
 	"github.com/alesr/resterrdemo/service/foo"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-const addr = ":8080"
+// The version 1 of the API, also served without prefix for the clients predating the versions,
+// is deprecated in favor of the version 2 and planned to stop being served at its sunset.
+var (
+	v1Deprecation = time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	v1Sunset      = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// traceFlushTimeout bounds the time spent exporting the last spans when the application stops.
+const traceFlushTimeout = 5 * time.Second
 
 func main() {
-	logger := slog.Default()
+	// Load the configuration from the flags, the environment and the configuration file.
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			config.Usage(os.Stderr)
+			fmt.Fprintln(os.Stderr)
+			cli.UsageExitCodes(os.Stderr)
+			os.Exit(0)
+		}
+
+		slog.Error("Invalid configuration.", errAttr(err))
+		os.Exit(12)
+	}
+
+	// Commands write their result to the standard output, so their logs go to the standard error.
+	logOutput := os.Stdout
+	if len(cfg.Command) > 0 {
+		logOutput = os.Stderr
+	}
+	logger := newLogger(logOutput, cfg.Log)
+
+	// Trace the requests across the transport, service and storage layers,
+	// continuing the traces propagated by the clients in the W3C trace context headers.
+
+	tp, err := newTracerProvider(cfg.Trace)
+	if err != nil {
+		logger.Error("Failed to initialize tracing.", errAttr(err))
+		os.Exit(13)
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// Open the database connection shared by the repositories.
+
+	db, err := postgres.Open(context.Background(), cfg.Database)
+	if err != nil {
+		logger.Error("Failed to open database.", errAttr(err))
+		os.Exit(8)
+	}
+
+	// Collect the metrics of the error handlers and the REST transport layer in a shared registry.
+
+	registry := prometheus.NewRegistry()
 
 	// Initialize foo storage, service (business) and transport error handler.
 
-	fooRepo := foorepo.NewPostgres()
-	fooSvc := foo.New(fooRepo)
+	fooRepo := foorepo.NewPostgres(db, cfg.Database, foorepo.WithTracerProvider(tp))
+	fooSvc := foo.New(fooRepo, foo.WithTracerProvider(tp))
 
-	fooErrHandler, err := resterr.NewHandler(logger, foohandler.ErrMap)
+	fooErrHandler, err := problem.NewHandler(
+		logger,
+		foohandler.ErrMap,
+		problem.WithMatchers(foohandler.NewErrMatchers(foohandler.ErrMap)...),
+		problem.WithKindDefaults(foo.Errors),
+		problem.WithJoinedErrors(problem.HighestStatus),
+		problem.WithMetrics(registry, "foo-v1"),
+	)
 	if err != nil {
 		logger.Error("Failed to initialize foo error handler.", errAttr(err))
 		os.Exit(1)
 	}
 
+	if err := foo.Errors.Validate(fooErrHandler.IsMapped); err != nil {
+		logger.Error("Foo error map does not match the foo service errors.", errAttr(err))
+		os.Exit(10)
+	}
+
 	fooHandler, err := foohandler.NewHandler(logger, fooSvc, fooErrHandler)
 	if err != nil {
 		logger.Error("Failed to initialize foo handler.", errAttr(err))
 		os.Exit(2)
 	}
 
+	// The version 2 of the API translates the foo errors with an error map of its own,
+	// so its errors are counted apart from the ones of the version 1.
+
+	fooErrHandlerV2, err := problem.NewHandler(
+		logger,
+		foohandler.ErrMapV2,
+		problem.WithMatchers(foohandler.NewErrMatchers(foohandler.ErrMapV2)...),
+		problem.WithKindDefaults(foo.Errors),
+		problem.WithJoinedErrors(problem.HighestStatus),
+		problem.WithMetrics(registry, "foo-v2"),
+	)
+	if err != nil {
+		logger.Error("Failed to initialize foo v2 error handler.", errAttr(err))
+		os.Exit(15)
+	}
+
+	if err := foo.Errors.Validate(fooErrHandlerV2.IsMapped); err != nil {
+		logger.Error("Foo v2 error map does not match the foo service errors.", errAttr(err))
+		os.Exit(16)
+	}
+
+	fooHandlerV2, err := foohandler.NewHandler(logger, fooSvc, fooErrHandlerV2)
+	if err != nil {
+		logger.Error("Failed to initialize foo v2 handler.", errAttr(err))
+		os.Exit(17)
+	}
+
 	// Initialize bar storage, service (business) and transport error handler.
 
-	barRepo := barrepo.NewPostgres()
-	barSvc := bar.New(barRepo)
+	barRepo := barrepo.NewPostgres(db, cfg.Database, barrepo.WithTracerProvider(tp))
+	barSvc := bar.New(barRepo, bar.WithTracerProvider(tp))
 
-	barErrHandler, err := resterr.NewHandler(logger, barhandler.ErrMap)
+	barErrHandler, err := problem.NewHandler(
+		logger,
+		barhandler.ErrMap,
+		problem.WithMatchers(barhandler.NewErrMatchers(barhandler.ErrMap)...),
+		problem.WithKindDefaults(bar.Errors),
+		problem.WithJoinedErrors(problem.HighestStatus),
+		problem.WithMetrics(registry, "bar"),
+	)
 	if err != nil {
 		logger.Error("Failed to initialize bar error handler.", errAttr(err))
 		os.Exit(3)
 	}
 
+	if err := bar.Errors.Validate(barErrHandler.IsMapped); err != nil {
+		logger.Error("Bar error map does not match the bar service errors.", errAttr(err))
+		os.Exit(11)
+	}
+
 	barHandler, err := barhandler.NewHandler(logger, barSvc, barErrHandler)
 	if err != nil {
 		logger.Error("Failed to initialize bar handler.", errAttr(err))
 		os.Exit(4)
 	}
 
+	// Run the command given on the command line, if any, instead of serving.
+	// The command line translates the errors with error maps of its own, into exit codes and messages.
+
+	if len(cfg.Command) > 0 {
+		fooCLIErrHandler, err := cli.NewErrorHandler(logger, cli.FooErrMap)
+		if err != nil {
+			logger.Error("Failed to initialize foo CLI error handler.", errAttr(err))
+			os.Exit(24)
+		}
+
+		if err := foo.Errors.Validate(fooCLIErrHandler.IsMapped); err != nil {
+			logger.Error("Foo CLI error map does not match the foo service errors.", errAttr(err))
+			os.Exit(25)
+		}
+
+		barCLIErrHandler, err := cli.NewErrorHandler(logger, cli.BarErrMap)
+		if err != nil {
+			logger.Error("Failed to initialize bar CLI error handler.", errAttr(err))
+			os.Exit(26)
+		}
+
+		if err := bar.Errors.Validate(barCLIErrHandler.IsMapped); err != nil {
+			logger.Error("Bar CLI error map does not match the bar service errors.", errAttr(err))
+			os.Exit(27)
+		}
+
+		cliApp := cli.NewApp(logger, os.Stdout, os.Stderr, fooSvc, fooCLIErrHandler, barSvc, barCLIErrHandler, cli.WithTracerProvider(tp))
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := cliApp.Run(ctx, cfg.Command)
+		stop()
+
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+		_ = db.Close()
+		_ = tp.Shutdown(flushCtx)
+		cancelFlush()
+		os.Exit(int(code))
+	}
+
 	// Inject handles on our REST transport layer.
 
-	restApp, err := rest.NewApp(logger, addr, fooHandler, barHandler)
+	restApp, err := rest.NewApp(
+		logger,
+		cfg.Server,
+		rest.WithVersion(rest.Version{
+			Name: "v1",
+			Resources: []rest.Resource{
+				{Name: "foo", Routes: rest.CRUD(fooHandler), ErrorHandler: fooErrHandler},
+				{Name: "bar", Routes: rest.CRUD(barHandler), ErrorHandler: barErrHandler},
+			},
+			Default:     true,
+			Deprecation: v1Deprecation,
+			Sunset:      v1Sunset,
+		}),
+		rest.WithVersion(rest.Version{
+			Name: "v2",
+			Resources: []rest.Resource{
+				{Name: "foo", Routes: rest.CRUD(fooHandlerV2), ErrorHandler: fooErrHandlerV2},
+				{Name: "bar", Routes: rest.CRUD(barHandler), ErrorHandler: barErrHandler},
+			},
+		}),
+		rest.WithMetrics(registry),
+		rest.WithTracerProvider(tp),
+		rest.WithDependency("foo-storage", fooRepo, fooErrHandler),
+		rest.WithDependency("bar-storage", barRepo, barErrHandler),
+	)
 	if err != nil {
 		logger.Error("Failed to initialize REST APP.", errAttr(err))
 		os.Exit(5)
 	}
 
-	go func() {
-		if err := restApp.Run(); err != nil {
-			logger.Error("Failed to run REST APP.", slog.String("addr", addr), errAttr(err))
-			os.Exit(6)
-		}
-	}()
+	// Initialize the gRPC transport layer, serving the same services with error maps of its own.
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	fooGRPCErrHandler, err := grpcapp.NewErrorHandler(logger, grpcapp.FooErrMap)
+	if err != nil {
+		logger.Error("Failed to initialize foo gRPC error handler.", errAttr(err))
+		os.Exit(18)
+	}
+
+	if err := foo.Errors.Validate(fooGRPCErrHandler.IsMapped); err != nil {
+		logger.Error("Foo gRPC error map does not match the foo service errors.", errAttr(err))
+		os.Exit(19)
+	}
+
+	barGRPCErrHandler, err := grpcapp.NewErrorHandler(logger, grpcapp.BarErrMap)
+	if err != nil {
+		logger.Error("Failed to initialize bar gRPC error handler.", errAttr(err))
+		os.Exit(20)
+	}
+
+	if err := bar.Errors.Validate(barGRPCErrHandler.IsMapped); err != nil {
+		logger.Error("Bar gRPC error map does not match the bar service errors.", errAttr(err))
+		os.Exit(21)
+	}
+
+	grpcServer := grpcapp.NewServer(logger, cfg.GRPC, fooSvc, fooGRPCErrHandler, barSvc, barGRPCErrHandler, grpcapp.WithTracerProvider(tp))
+
+	// Serve both transports side by side until one of them fails, or a termination signal is received.
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	<-ctx.Done()
+	restErr := make(chan error, 1)
+	go func() { restErr <- restApp.Run() }()
+
+	grpcErr := make(chan error, 1)
+	go func() { grpcErr <- grpcServer.Run() }()
+
+	select {
+	case err := <-restErr:
+		logger.Error("Failed to run REST APP.", slog.String("addr", cfg.Server.Addr), errAttr(err))
+		_ = db.Close()
+		os.Exit(6)
+	case err := <-grpcErr:
+		logger.Error("Failed to run gRPC server.", slog.String("addr", cfg.GRPC.Addr), errAttr(err))
+		_ = db.Close()
+		os.Exit(22)
+	case <-ctx.Done():
+		logger.Info("Termination signal received.", slog.Duration("grace-period", cfg.Server.ShutdownGracePeriod))
+	}
+
+	// Restore the default behavior of the signals, so that a second signal terminates the application immediately.
+	stop()
+
+	// Drain the requests in flight within the grace period, then close the resources they were using.
 
-	if err := restApp.Shutdown(ctx); err != nil {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownGracePeriod)
+	defer cancel()
+
+	// The gRPC server has no readiness check to fail, so it drains its calls while the REST APP waits for the shutdown delay.
+	grpcShutdownErr := make(chan error, 1)
+	go func() { grpcShutdownErr <- grpcServer.Shutdown(shutdownCtx) }()
+
+	var exitCode int
+	if err := restApp.Shutdown(shutdownCtx); err != nil {
 		logger.Error("Failed to shutdown REST APP.", errAttr(err))
-		os.Exit(7)
+		exitCode = 7
+	}
+
+	if err := <-grpcShutdownErr; err != nil {
+		logger.Error("Failed to shutdown gRPC server.", errAttr(err))
+		exitCode = 23
+	}
+
+	if err := db.Close(); err != nil {
+		logger.Error("Failed to close database.", errAttr(err))
+		exitCode = 9
+	}
+
+	// The drain may have used up the grace period, so the spans of the drained requests are flushed within a timeout of their own.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancelFlush()
+
+	if err := tp.Shutdown(flushCtx); err != nil {
+		logger.Error("Failed to flush spans.", errAttr(err))
+		exitCode = 14
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+	logger.Info("Shutdown complete.")
+}
+
+// newLogger returns the application logger writing to w, adding the request ID to the records
+// emitted while handling a request.
+func newLogger(w io.Writer, cfg config.Log) *slog.Logger {
+	opts := slog.HandlerOptions{Level: cfg.Level}
+
+	var h slog.Handler = slog.NewTextHandler(w, &opts)
+	if cfg.Format == config.FormatJSON {
+		h = slog.NewJSONHandler(w, &opts)
+	}
+	return slog.New(requestid.NewLogHandler(h))
+}
+
+// newTracerProvider returns the provider of the tracers of the application,
+// exporting the spans as configured.
+func newTracerProvider(cfg config.Trace) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "resterrdemo"))),
+	}
+
+	if cfg.Exporter == config.ExporterStdout {
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return nil, fmt.Errorf("could not create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
 	}
+	return sdktrace.NewTracerProvider(opts...), nil
 }
 
 func errAttr(err error) slog.Attr {
@@ -0,0 +1,78 @@
+package problemtype
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ContentType is the media type of problem details responses.
+const ContentType = "application/problem+json"
+
+// Details is an RFC 9457 problem details object, as sent by the API and decoded by the client.
+// Extensions are marshaled as top level members next to the standard ones.
+type Details struct {
+	Type       string
+	Status     int
+	Title      string
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d Details) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(d.Extensions)+5)
+	for k, v := range d.Extensions {
+		members[k] = v
+	}
+
+	members["type"] = d.Type
+	members["status"] = d.Status
+	members["title"] = d.Title
+
+	if d.Detail != "" {
+		members["detail"] = d.Detail
+	}
+
+	if d.Instance != "" {
+		members["instance"] = d.Instance
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Members other than the standard ones are collected as extensions.
+func (d *Details) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	standard := map[string]any{
+		"type":     &d.Type,
+		"status":   &d.Status,
+		"title":    &d.Title,
+		"detail":   &d.Detail,
+		"instance": &d.Instance,
+	}
+
+	for k, raw := range members {
+		if dst, ok := standard[k]; ok {
+			if err := json.Unmarshal(raw, dst); err != nil {
+				return fmt.Errorf("could not unmarshal problem member '%s': %w", k, err)
+			}
+			continue
+		}
+
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return fmt.Errorf("could not unmarshal problem extension '%s': %w", k, err)
+		}
+
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any)
+		}
+		d.Extensions[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// problemtype package declares the problem details the REST API reports errors with: their encoding,
+// their types and the errors of the services they are translated from. It is shared by the handlers,
+// which send the problems, and by the client, which decodes them back into errors, without depending on the server.
+package problemtype
+
+import (
+	"github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/foo"
+)
+
+// Problem types identify the kind of problem reported to the client.
+// They are part of the API contract: clients may rely on them, so once published they should not change.
+const (
+	RequestCanceled = "https://api.resterrdemo.example/problems/request-canceled"
+	RequestTimeout  = "https://api.resterrdemo.example/problems/request-timeout"
+
+	FooInvalidID    = "https://api.resterrdemo.example/problems/foo/invalid-id"
+	FooInvalidBody  = "https://api.resterrdemo.example/problems/foo/invalid-body"
+	FooNotFound     = "https://api.resterrdemo.example/problems/foo/not-found"
+	FooInvalid      = "https://api.resterrdemo.example/problems/foo/invalid"
+	FooGetFailed    = "https://api.resterrdemo.example/problems/foo/get-failed"
+	FooListFailed   = "https://api.resterrdemo.example/problems/foo/list-failed"
+	FooCreateFailed = "https://api.resterrdemo.example/problems/foo/create-failed"
+	FooUpdateFailed = "https://api.resterrdemo.example/problems/foo/update-failed"
+	FooDeleteFailed = "https://api.resterrdemo.example/problems/foo/delete-failed"
+
+	BarInvalidID   = "https://api.resterrdemo.example/problems/bar/invalid-id"
+	BarInvalidBody = "https://api.resterrdemo.example/problems/bar/invalid-body"
+	BarNotFound    = "https://api.resterrdemo.example/problems/bar/not-found"
+	BarInvalid     = "https://api.resterrdemo.example/problems/bar/invalid"
+	BarNameTaken   = "https://api.resterrdemo.example/problems/bar/name-taken"
+)
+
+// Errors maps the problem types to the errors of the services they are translated from, in every version of the API.
+// The types of the errors of the handlers themselves, such as an invalid ID, and of the requests the API gave up on,
+// have no service error to be decoded into.
+var Errors = map[string]error{
+	FooNotFound:     foo.ErrFooNotFound,
+	FooInvalid:      foo.ErrInvalidFoo,
+	FooGetFailed:    foo.ErrGetFaleid,
+	FooListFailed:   foo.ErrListFailed,
+	FooCreateFailed: foo.ErrCreateFailed,
+	FooUpdateFailed: foo.ErrUpdateFailed,
+	FooDeleteFailed: foo.ErrDeleteFailed,
+
+	BarNotFound:  bar.ErrNoSuchBar,
+	BarInvalid:   bar.ErrInvalidBar,
+	BarNameTaken: bar.ErrBarNameTaken,
+}
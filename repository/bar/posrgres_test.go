@@ -1,9 +1,20 @@
 package bar
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"os"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alesr/resterrdemo/config"
 	domain "github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -11,17 +22,385 @@ import (
 func TestNewPostgres(t *testing.T) {
 	t.Parallel()
 
-	got := NewPostgres()
+	db := &sql.DB{}
+
+	got := NewPostgres(db, config.Database{QueryTimeout: time.Second})
 
 	require.NotNil(t, got)
 	assert.IsType(t, &Postgresql{}, got)
+	assert.Equal(t, db, got.db)
+	assert.Equal(t, time.Second, got.queryTimeout)
 }
 
 func TestPostgresql_Fetch(t *testing.T) {
 	t.Parallel()
 
-	pg := Postgresql{}
-	got := pg.Fetch()
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedBar   domain.Bar
+		expectedError error
+	}{
+		{
+			name: "record found",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "bar"))
+			},
+			expectedBar: domain.Bar{ID: 42, Name: "bar"},
+		},
+		{
+			name: "no rows",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedError: domain.ErrBarNotFound,
+		},
+		{
+			name: "connection failure",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+		{
+			name: "network timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded})
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+		{
+			name: "query timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+		{
+			name: "context canceled",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.Canceled)
+			},
+			expectedError: context.Canceled,
+		},
+		{
+			name: "unexpected error",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(assert.AnError)
+			},
+			expectedError: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).WithArgs(42))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.Fetch(context.TODO(), 42)
+
+			assert.Equal(t, tc.expectedBar, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+
+			if errors.Is(err, domain.ErrBarNotFound) {
+				var domainErr *domainerr.Error
+				require.ErrorAs(t, err, &domainErr)
+				assert.Equal(t, "42", domainErr.Metadata["id"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_List(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedBars  []domain.Bar
+		expectedError error
+	}{
+		{
+			name: "records found",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "first").AddRow(2, "second"))
+			},
+			expectedBars: []domain.Bar{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}},
+		},
+		{
+			name: "no records",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedBars: []domain.Bar{},
+		},
+		{
+			name: "connection failure",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(listQuery)))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.List(context.TODO())
+
+			assert.Equal(t, tc.expectedBars, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Create(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedBar   domain.Bar
+		expectedError error
+	}{
+		{
+			name: "record created",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "bar"))
+			},
+			expectedBar: domain.Bar{ID: 42, Name: "bar"},
+		},
+		{
+			name: "duplicate name",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			expectedError: domain.ErrBarDuplicate,
+		},
+		{
+			name: "network timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded})
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+		{
+			name: "query timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+		{
+			name: "context canceled",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.Canceled)
+			},
+			expectedError: context.Canceled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(createQuery)).WithArgs("bar"))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.Create(context.TODO(), domain.Bar{Name: "bar"})
+
+			assert.Equal(t, tc.expectedBar, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedBar   domain.Bar
+		expectedError error
+	}{
+		{
+			name: "record updated",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "bar"))
+			},
+			expectedBar: domain.Bar{ID: 42, Name: "bar"},
+		},
+		{
+			name: "no rows",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedError: domain.ErrBarNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).WithArgs(42, "bar"))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.Update(context.TODO(), domain.Bar{ID: 42, Name: "bar"})
+
+			assert.Equal(t, tc.expectedBar, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Delete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(exec *sqlmock.ExpectedExec)
+		expectedError error
+	}{
+		{
+			name: "record deleted",
+			expect: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "no rows affected",
+			expect: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: domain.ErrBarNotFound,
+		},
+		{
+			name: "connection failure",
+			expect: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(42))
+
+			pg := NewPostgres(db, config.Database{})
+			err = pg.Delete(context.TODO(), 42)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Ping(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(ping *sqlmock.ExpectedPing)
+		expectedError error
+	}{
+		{
+			name:   "database reachable",
+			expect: func(ping *sqlmock.ExpectedPing) {},
+		},
+		{
+			name: "connection failure",
+			expect: func(ping *sqlmock.ExpectedPing) {
+				ping.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+		{
+			name: "ping timeout",
+			expect: func(ping *sqlmock.ExpectedPing) {
+				ping.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: domain.ErrBarStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectPing())
+
+			pg := NewPostgres(db, config.Database{})
+			err = pg.Ping(context.TODO())
 
-	assert.Equal(t, domain.ErrBarNotFound, got)
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
 }
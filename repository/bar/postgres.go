@@ -1,16 +1,199 @@
 package bar
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/repository/postgres"
 	domain "github.com/alesr/resterrdemo/service/bar"
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	fetchQuery  = `SELECT id, name FROM bar WHERE id = $1`
+	listQuery   = `SELECT id, name FROM bar ORDER BY id`
+	createQuery = `INSERT INTO bar (name) VALUES ($1) RETURNING id, name`
+	updateQuery = `UPDATE bar SET name = $2 WHERE id = $1 RETURNING id, name`
+	deleteQuery = `DELETE FROM bar WHERE id = $1`
 )
 
 // Postgresql carries the connection to the database,
 // and the methods to interact with it.
-type Postgresql struct{}
+type Postgresql struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	tracer       trace.Tracer
+}
+
+// Option applies custom behavior to the repository.
+type Option func(p *Postgresql)
+
+// WithTracerProvider is an option to trace the queries with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *Postgresql) {
+		p.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// tracerName is the name of the tracer of the repository.
+const tracerName = "github.com/alesr/resterrdemo/repository/bar"
 
 // NewPostgres instantiates a new Postgresql struct.
-func NewPostgres() *Postgresql { return &Postgresql{} }
+// The queries are bounded by the query timeout of the configuration, if any.
+func NewPostgres(db *sql.DB, cfg config.Database, opts ...Option) *Postgresql {
+	p := Postgresql{db: db, queryTimeout: cfg.QueryTimeout, tracer: tracing.Tracer(nil, tracerName)}
+	for _, o := range opts {
+		o(&p)
+	}
+	return &p
+}
+
+// Fetch fetches the bar entity with the given ID from the database.
+func (p *Postgresql) Fetch(ctx context.Context, id int64) (domain.Bar, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "bar.Postgresql.Fetch", "bar", "SELECT")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	var bar domain.Bar
+	if err := p.db.QueryRowContext(queryCtx, fetchQuery, id).Scan(&bar.ID, &bar.Name); err != nil {
+		return domain.Bar{}, tracing.RecordError(span, translateIDErr(ctx, err, "fetch", id))
+	}
+	return bar, nil
+}
+
+// List fetches all bar entities from the database, ordered by ID.
+func (p *Postgresql) List(ctx context.Context) ([]domain.Bar, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "bar.Postgresql.List", "bar", "SELECT")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(queryCtx, listQuery)
+	if err != nil {
+		return nil, tracing.RecordError(span, translateErr(ctx, err, "list"))
+	}
+	defer rows.Close()
+
+	bars := []domain.Bar{}
+	for rows.Next() {
+		var bar domain.Bar
+		if err := rows.Scan(&bar.ID, &bar.Name); err != nil {
+			return nil, tracing.RecordError(span, translateErr(ctx, err, "list"))
+		}
+		bars = append(bars, bar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, tracing.RecordError(span, translateErr(ctx, err, "list"))
+	}
+	return bars, nil
+}
+
+// Create inserts a new bar entity and returns it with the ID assigned by the database.
+func (p *Postgresql) Create(ctx context.Context, bar domain.Bar) (domain.Bar, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "bar.Postgresql.Create", "bar", "INSERT")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	var created domain.Bar
+	if err := p.db.QueryRowContext(queryCtx, createQuery, bar.Name).Scan(&created.ID, &created.Name); err != nil {
+		return domain.Bar{}, tracing.RecordError(span, translateErr(ctx, err, "create"))
+	}
+	return created, nil
+}
+
+// Update replaces the bar entity matching the ID of the given bar.
+func (p *Postgresql) Update(ctx context.Context, bar domain.Bar) (domain.Bar, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "bar.Postgresql.Update", "bar", "UPDATE")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	var updated domain.Bar
+	if err := p.db.QueryRowContext(queryCtx, updateQuery, bar.ID, bar.Name).Scan(&updated.ID, &updated.Name); err != nil {
+		return domain.Bar{}, tracing.RecordError(span, translateIDErr(ctx, err, "update", bar.ID))
+	}
+	return updated, nil
+}
+
+// Delete deletes the bar entity with the given ID.
+func (p *Postgresql) Delete(ctx context.Context, id int64) error {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "bar.Postgresql.Delete", "bar", "DELETE")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	res, err := p.db.ExecContext(queryCtx, deleteQuery, id)
+	if err != nil {
+		return tracing.RecordError(span, translateIDErr(ctx, err, "delete", id))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return tracing.RecordError(span, translateIDErr(ctx, err, "delete", id))
+	}
+
+	if affected == 0 {
+		return tracing.RecordError(span, translateIDErr(ctx, sql.ErrNoRows, "delete", id))
+	}
+	return nil
+}
+
+// Ping checks that the database storing the bar entities is reachable.
+func (p *Postgresql) Ping(ctx context.Context) error {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "bar.Postgresql.Ping", "bar", "ping")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	if err := p.db.PingContext(queryCtx); err != nil {
+		return tracing.RecordError(span, translateErr(ctx, err, "ping"))
+	}
+	return nil
+}
+
+// translateIDErr translates driver errors of operations on the bar entity with the given ID.
+// The ID is added to the not found error, since it describes which entity is missing.
+func translateIDErr(ctx context.Context, err error, op string, id int64) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not %s bar: %w", op, domain.ErrBarNotFound.With("id", strconv.FormatInt(id, 10)))
+	}
+	return translateErr(ctx, err, op)
+}
+
+// translateErr translates driver errors into the domain errors the service layer knows about,
+// so the details of the storage never leak into the layers above.
+// When the context of the caller is done, its error is kept: it describes the caller giving up, not the storage failing.
+// Otherwise, a query running out of the query timeout means the storage did not answer in time.
+func translateErr(ctx context.Context, err error, op string) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("could not %s bar: '%s': %w", op, err, ctxErr)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not %s bar: %w", op, domain.ErrBarNotFound)
+	}
+
+	if postgres.IsUniqueViolation(err) {
+		return fmt.Errorf("could not %s bar: %w", op, domain.ErrBarDuplicate)
+	}
 
-// Fetch fetches foo entities from the database.
-// In our example, we simulate that we couldn't find a record.
-func (p Postgresql) Fetch() error { return domain.ErrBarNotFound }
+	if postgres.IsUnavailable(err) {
+		return fmt.Errorf("could not reach bar storage: '%s': %w", err, domain.ErrBarStorageUnavailable)
+	}
+	return fmt.Errorf("could not %s bar: %w", op, err)
+}
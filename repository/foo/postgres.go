@@ -1,19 +1,195 @@
 package foo
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/repository/postgres"
+	domain "github.com/alesr/resterrdemo/service/foo"
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var errNetworkKaput = errors.New("network kaput")
+const (
+	fetchQuery  = `SELECT id, name FROM foo WHERE id = $1`
+	listQuery   = `SELECT id, name FROM foo ORDER BY id`
+	createQuery = `INSERT INTO foo (name) VALUES ($1) RETURNING id, name`
+	updateQuery = `UPDATE foo SET name = $2 WHERE id = $1 RETURNING id, name`
+	deleteQuery = `DELETE FROM foo WHERE id = $1`
+)
 
 // Postgresql carries the connection to the database,
 // and the methods to interact with it.
-type Postgresql struct{}
+type Postgresql struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	tracer       trace.Tracer
+}
+
+// Option applies custom behavior to the repository.
+type Option func(p *Postgresql)
+
+// WithTracerProvider is an option to trace the queries with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *Postgresql) {
+		p.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// tracerName is the name of the tracer of the repository.
+const tracerName = "github.com/alesr/resterrdemo/repository/foo"
 
 // NewPostgres instantiates a new Postgresql struct.
-func NewPostgres() *Postgresql { return &Postgresql{} }
+// The queries are bounded by the query timeout of the configuration, if any.
+func NewPostgres(db *sql.DB, cfg config.Database, opts ...Option) *Postgresql {
+	p := Postgresql{db: db, queryTimeout: cfg.QueryTimeout, tracer: tracing.Tracer(nil, tracerName)}
+	for _, o := range opts {
+		o(&p)
+	}
+	return &p
+}
+
+// Fetch fetches the foo entity with the given ID from the database.
+func (p *Postgresql) Fetch(ctx context.Context, id int64) (domain.Foo, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "foo.Postgresql.Fetch", "foo", "SELECT")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	var foo domain.Foo
+	if err := p.db.QueryRowContext(queryCtx, fetchQuery, id).Scan(&foo.ID, &foo.Name); err != nil {
+		return domain.Foo{}, tracing.RecordError(span, translateIDErr(ctx, err, "fetch", id))
+	}
+	return foo, nil
+}
+
+// List fetches all foo entities from the database, ordered by ID.
+func (p *Postgresql) List(ctx context.Context) ([]domain.Foo, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "foo.Postgresql.List", "foo", "SELECT")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(queryCtx, listQuery)
+	if err != nil {
+		return nil, tracing.RecordError(span, translateErr(ctx, err, "list"))
+	}
+	defer rows.Close()
+
+	foos := []domain.Foo{}
+	for rows.Next() {
+		var foo domain.Foo
+		if err := rows.Scan(&foo.ID, &foo.Name); err != nil {
+			return nil, tracing.RecordError(span, translateErr(ctx, err, "list"))
+		}
+		foos = append(foos, foo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, tracing.RecordError(span, translateErr(ctx, err, "list"))
+	}
+	return foos, nil
+}
+
+// Create inserts a new foo entity and returns it with the ID assigned by the database.
+func (p *Postgresql) Create(ctx context.Context, foo domain.Foo) (domain.Foo, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "foo.Postgresql.Create", "foo", "INSERT")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	var created domain.Foo
+	if err := p.db.QueryRowContext(queryCtx, createQuery, foo.Name).Scan(&created.ID, &created.Name); err != nil {
+		return domain.Foo{}, tracing.RecordError(span, translateErr(ctx, err, "create"))
+	}
+	return created, nil
+}
+
+// Update replaces the foo entity matching the ID of the given foo.
+func (p *Postgresql) Update(ctx context.Context, foo domain.Foo) (domain.Foo, error) {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "foo.Postgresql.Update", "foo", "UPDATE")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	var updated domain.Foo
+	if err := p.db.QueryRowContext(queryCtx, updateQuery, foo.ID, foo.Name).Scan(&updated.ID, &updated.Name); err != nil {
+		return domain.Foo{}, tracing.RecordError(span, translateIDErr(ctx, err, "update", foo.ID))
+	}
+	return updated, nil
+}
+
+// Delete deletes the foo entity with the given ID.
+func (p *Postgresql) Delete(ctx context.Context, id int64) error {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "foo.Postgresql.Delete", "foo", "DELETE")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	res, err := p.db.ExecContext(queryCtx, deleteQuery, id)
+	if err != nil {
+		return tracing.RecordError(span, translateIDErr(ctx, err, "delete", id))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return tracing.RecordError(span, translateIDErr(ctx, err, "delete", id))
+	}
+
+	if affected == 0 {
+		return tracing.RecordError(span, translateIDErr(ctx, sql.ErrNoRows, "delete", id))
+	}
+	return nil
+}
+
+// Ping checks that the database storing the foo entities is reachable.
+func (p *Postgresql) Ping(ctx context.Context) error {
+	ctx, span := postgres.StartSpan(ctx, p.tracer, "foo.Postgresql.Ping", "foo", "ping")
+	defer span.End()
+
+	queryCtx, cancel := postgres.WithQueryTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	if err := p.db.PingContext(queryCtx); err != nil {
+		return tracing.RecordError(span, translateErr(ctx, err, "ping"))
+	}
+	return nil
+}
+
+// translateIDErr translates driver errors of operations on the foo entity with the given ID.
+// The ID is added to the not found error, since it describes which entity is missing.
+func translateIDErr(ctx context.Context, err error, op string, id int64) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not %s foo: %w", op, domain.ErrFooNotFound.With("id", strconv.FormatInt(id, 10)))
+	}
+	return translateErr(ctx, err, op)
+}
+
+// translateErr translates driver errors into the domain errors the service layer knows about,
+// so the details of the storage never leak into the layers above.
+// When the context of the caller is done, its error is kept: it describes the caller giving up, not the storage failing.
+// Otherwise, a query running out of the query timeout means the storage did not answer in time.
+func translateErr(ctx context.Context, err error, op string) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("could not %s foo: '%s': %w", op, err, ctxErr)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not %s foo: %w", op, domain.ErrFooNotFound)
+	}
 
-// Fetch fetches foo entities from the database.
-// In our example, we simulate an network error which we would not
-// like to expose on HTTP responses.
-func (p Postgresql) Fetch() error { return errNetworkKaput }
+	if postgres.IsUnavailable(err) {
+		return fmt.Errorf("could not reach foo storage: '%s': %w", err, domain.ErrFooStorageUnavailable)
+	}
+	return fmt.Errorf("could not %s foo: %w", op, err)
+}
@@ -1,26 +1,494 @@
 package foo
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"os"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alesr/resterrdemo/config"
+	"github.com/alesr/resterrdemo/service/domainerr"
+	domain "github.com/alesr/resterrdemo/service/foo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewPostgres(t *testing.T) {
 	t.Parallel()
 
-	got := NewPostgres()
+	db := &sql.DB{}
+
+	got := NewPostgres(db, config.Database{QueryTimeout: time.Second})
 
 	require.NotNil(t, got)
 	assert.IsType(t, &Postgresql{}, got)
+	assert.Equal(t, db, got.db)
+	assert.Equal(t, time.Second, got.queryTimeout)
 }
 
 func TestPostgresql_Fetch(t *testing.T) {
 	t.Parallel()
 
-	pg := Postgresql{}
-	got := pg.Fetch()
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedFoo   domain.Foo
+		expectedError error
+	}{
+		{
+			name: "record found",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "foo"))
+			},
+			expectedFoo: domain.Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name: "no rows",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedError: domain.ErrFooNotFound,
+		},
+		{
+			name: "connection failure",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+		{
+			name: "network timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded})
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+		{
+			name: "query timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+		{
+			name: "context canceled",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.Canceled)
+			},
+			expectedError: context.Canceled,
+		},
+		{
+			name: "unexpected error",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(assert.AnError)
+			},
+			expectedError: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).WithArgs(42))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.Fetch(context.TODO(), 42)
+
+			assert.Equal(t, tc.expectedFoo, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+
+			if errors.Is(err, domain.ErrFooNotFound) {
+				var domainErr *domainerr.Error
+				require.ErrorAs(t, err, &domainErr)
+				assert.Equal(t, "42", domainErr.Metadata["id"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_List(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedFoos  []domain.Foo
+		expectedError error
+	}{
+		{
+			name: "records found",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "first").AddRow(2, "second"))
+			},
+			expectedFoos: []domain.Foo{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}},
+		},
+		{
+			name: "no records",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedFoos: []domain.Foo{},
+		},
+		{
+			name: "connection failure",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(listQuery)))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.List(context.TODO())
+
+			assert.Equal(t, tc.expectedFoos, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Create(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedFoo   domain.Foo
+		expectedError error
+	}{
+		{
+			name: "record created",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "foo"))
+			},
+			expectedFoo: domain.Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name: "network timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded})
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+		{
+			name: "query timeout",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+		{
+			name: "context canceled",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnError(context.Canceled)
+			},
+			expectedError: context.Canceled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(createQuery)).WithArgs("foo"))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.Create(context.TODO(), domain.Foo{Name: "foo"})
+
+			assert.Equal(t, tc.expectedFoo, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(query *sqlmock.ExpectedQuery)
+		expectedFoo   domain.Foo
+		expectedError error
+	}{
+		{
+			name: "record updated",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "foo"))
+			},
+			expectedFoo: domain.Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name: "no rows",
+			expect: func(query *sqlmock.ExpectedQuery) {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expectedError: domain.ErrFooNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).WithArgs(42, "foo"))
+
+			pg := NewPostgres(db, config.Database{})
+			got, err := pg.Update(context.TODO(), domain.Foo{ID: 42, Name: "foo"})
+
+			assert.Equal(t, tc.expectedFoo, got)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Delete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(exec *sqlmock.ExpectedExec)
+		expectedError error
+	}{
+		{
+			name: "record deleted",
+			expect: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "no rows affected",
+			expect: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: domain.ErrFooNotFound,
+		},
+		{
+			name: "connection failure",
+			expect: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(42))
+
+			pg := NewPostgres(db, config.Database{})
+			err = pg.Delete(context.TODO(), 42)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Ping(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		expect        func(ping *sqlmock.ExpectedPing)
+		expectedError error
+	}{
+		{
+			name:   "database reachable",
+			expect: func(ping *sqlmock.ExpectedPing) {},
+		},
+		{
+			name: "connection failure",
+			expect: func(ping *sqlmock.ExpectedPing) {
+				ping.WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+		{
+			name: "ping timeout",
+			expect: func(ping *sqlmock.ExpectedPing) {
+				ping.WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: domain.ErrFooStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.expect(mock.ExpectPing())
+
+			pg := NewPostgres(db, config.Database{})
+			err = pg.Ping(context.TODO())
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_QueryTimeout(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		requestTimeout  time.Duration
+		queryTimeout    time.Duration
+		expectedError   error
+		unexpectedError error
+	}{
+		{
+			name:            "query times out while the request is live",
+			requestTimeout:  time.Minute,
+			queryTimeout:    10 * time.Millisecond,
+			expectedError:   domain.ErrFooStorageUnavailable,
+			unexpectedError: context.DeadlineExceeded,
+		},
+		{
+			name:            "request times out before the query",
+			requestTimeout:  10 * time.Millisecond,
+			queryTimeout:    time.Minute,
+			expectedError:   context.DeadlineExceeded,
+			unexpectedError: domain.ErrFooStorageUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			// The only connection of the pool is held, so the query waits for one until a deadline expires.
+			db.SetMaxOpenConns(1)
+			conn, err := db.Conn(context.TODO())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.TODO(), tc.requestTimeout)
+			defer cancel()
+
+			pg := NewPostgres(db, config.Database{QueryTimeout: tc.queryTimeout})
+			_, err = pg.Fetch(ctx, 42)
+
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.NotErrorIs(t, err, tc.unexpectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresql_Tracing(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).WithArgs(42).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "foo"))
+	mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).WithArgs(43).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	exporter := tracetest.NewInMemoryExporter()
+	pg := NewPostgres(db, config.Database{}, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+
+	_, err = pg.Fetch(context.TODO(), 42)
+	require.NoError(t, err)
+
+	_, err = pg.Fetch(context.TODO(), 43)
+	require.ErrorIs(t, err, domain.ErrFooNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	for _, span := range spans {
+		assert.Equal(t, "foo.Postgresql.Fetch", span.Name)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Subset(t, span.Attributes, []attribute.KeyValue{
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.collection.name", "foo"),
+			attribute.String("db.operation.name", "SELECT"),
+		})
+	}
 
-	assert.Equal(t, errNetworkKaput, got)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, err.Error(), spans[1].Status.Description)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
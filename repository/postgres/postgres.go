@@ -0,0 +1,119 @@
+// postgres package holds the pieces shared by the PostgreSQL repositories,
+// such as opening the connection pool and classifying driver errors.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/alesr/resterrdemo/config"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	// Register the pgx driver under the "pgx" name for database/sql.
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const driverName = "pgx"
+
+// Open opens a connection pool to the database described by the configuration
+// and verifies that the database is reachable.
+func Open(ctx context.Context, cfg config.Database) (*sql.DB, error) {
+	db, err := sql.Open(driverName, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not ping database: %w", err)
+	}
+	return db, nil
+}
+
+// WithQueryTimeout returns a copy of the context bounding the duration of a query.
+// A zero timeout leaves the context deadline unchanged.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// StartSpan starts the client span of an operation on the table,
+// described with the database attributes of the OpenTelemetry semantic conventions.
+func StartSpan(ctx context.Context, tracer trace.Tracer, name, table, op string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.collection.name", table),
+			attribute.String("db.operation.name", op),
+		),
+	)
+}
+
+// IsUnavailable reports whether the error returned by the driver means the database
+// could not be reached or did not answer in time, as opposed to the query itself failing.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return true
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return isUnavailableCode(pgErr.Code)
+	}
+	return false
+}
+
+// IsUniqueViolation reports whether the error returned by the driver
+// is caused by a unique constraint being violated.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isUnavailableCode reports whether the SQLSTATE code describes a server that
+// is not accepting or has dropped the connection.
+func isUnavailableCode(code string) bool {
+	// Class 08 - Connection Exception.
+	if len(code) == 5 && code[:2] == "08" {
+		return true
+	}
+
+	switch code {
+	case "53300", // too_many_connections
+		"57P01", // admin_shutdown
+		"57P02", // crash_shutdown
+		"57P03": // cannot_connect_now
+		return true
+	}
+	return false
+}
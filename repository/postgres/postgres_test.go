@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		given error
+		want  bool
+	}{
+		{
+			name:  "nil error",
+			given: nil,
+			want:  false,
+		},
+		{
+			name:  "no rows",
+			given: sql.ErrNoRows,
+			want:  false,
+		},
+		{
+			name:  "bad connection",
+			given: fmt.Errorf("query: %w", driver.ErrBadConn),
+			want:  true,
+		},
+		{
+			name:  "connection done",
+			given: sql.ErrConnDone,
+			want:  true,
+		},
+		{
+			name:  "deadline exceeded",
+			given: fmt.Errorf("query: %w", context.DeadlineExceeded),
+			want:  true,
+		},
+		{
+			name:  "network error",
+			given: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want:  true,
+		},
+		{
+			name:  "connection exception class",
+			given: &pgconn.PgError{Code: "08006"},
+			want:  true,
+		},
+		{
+			name:  "admin shutdown",
+			given: &pgconn.PgError{Code: "57P01"},
+			want:  true,
+		},
+		{
+			name:  "undefined table",
+			given: &pgconn.PgError{Code: "42P01"},
+			want:  false,
+		},
+		{
+			name:  "unexpected error",
+			given: assert.AnError,
+			want:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, IsUnavailable(tc.given))
+		})
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsUniqueViolation(fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"})))
+	assert.False(t, IsUniqueViolation(&pgconn.PgError{Code: "23503"}))
+	assert.False(t, IsUniqueViolation(assert.AnError))
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := WithQueryTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	ctx, cancel = WithQueryTimeout(context.Background(), 0)
+	cancel()
+
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
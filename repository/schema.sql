@@ -0,0 +1,11 @@
+-- Schema expected by the PostgreSQL repositories.
+
+CREATE TABLE IF NOT EXISTS foo (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bar (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
@@ -0,0 +1,141 @@
+// requestid package assigns an identifier to each request, so that what the client
+// receives can be correlated with the log records produced while handling the request.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	// Header is the header carrying the request ID, in requests and responses.
+	Header = "X-Request-ID"
+
+	// LogKey is the key of the request ID attribute added to the log records.
+	LogKey = "request-id"
+
+	// maxLen is the maximum length of a request ID accepted from the client.
+	maxLen = 128
+)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of the context carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID stored in the context, if any.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Middleware stores the request ID in the request context and sends it back in the response headers.
+// The ID provided by the client is used when it is valid. Otherwise, a new one is generated.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := Resolve(r.Header.Get(Header))
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// Resolve returns the request ID provided by the client if it is valid, or a new one otherwise.
+// It serves the transports that cannot use Middleware.
+func Resolve(id string) string {
+	if !valid(id) {
+		return generate()
+	}
+	return id
+}
+
+// valid reports whether the request ID provided by the client can be used as is.
+// Only short IDs made of printable ASCII characters are accepted,
+// so the ID cannot be used to forge log records or response headers.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// generate returns a new random request ID.
+func generate() string {
+	var b [16]byte
+
+	// crypto/rand.Read never returns an error on the supported platforms.
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// LogHandler is a slog handler adding the request ID stored in the context to the records.
+// The request ID is added at the top level of the record, even for loggers with groups.
+type LogHandler struct {
+	base slog.Handler
+	next slog.Handler
+	ops  []func(h slog.Handler) slog.Handler
+}
+
+// NewLogHandler returns a log handler adding the request ID to the records handled by next.
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{base: next, next: next}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements the slog.Handler interface.
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	id := FromContext(ctx)
+	if id == "" {
+		return h.next.Handle(ctx, r)
+	}
+
+	// The groups and attributes of the logger are applied after the request ID,
+	// so that the request ID is not nested in the groups.
+	next := h.base.WithAttrs([]slog.Attr{slog.String(LogKey, id)})
+	for _, op := range h.ops {
+		next = op(next)
+	}
+	return next.Handle(ctx, r)
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *LogHandler) with(op func(h slog.Handler) slog.Handler) *LogHandler {
+	ops := make([]func(h slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+
+	return &LogHandler{
+		base: h.base,
+		next: op(h.next),
+		ops:  append(ops, op),
+	}
+}
@@ -0,0 +1,162 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		given        string
+		expectedKept bool
+	}{
+		{
+			name:         "client request ID",
+			given:        "b7c1f1a0-6d2e-4f0b-9a53-6c1f0e2c8a11",
+			expectedKept: true,
+		},
+		{
+			name: "missing request ID",
+		},
+		{
+			name:  "request ID with control characters",
+			given: "abc\ndef",
+		},
+		{
+			name:  "request ID with spaces",
+			given: "abc def",
+		},
+		{
+			name:  "too long request ID",
+			given: strings.Repeat("a", maxLen+1),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/foo/1", nil)
+			if tc.given != "" {
+				req.Header.Set(Header, tc.given)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, got, rec.Header().Get(Header))
+
+			if tc.expectedKept {
+				assert.Equal(t, tc.given, got)
+				return
+			}
+
+			assert.NotEqual(t, tc.given, got)
+			assert.Len(t, got, 32)
+		})
+	}
+}
+
+func TestMiddleware_GeneratesUniqueIDs(t *testing.T) {
+	t.Parallel()
+
+	seen := make(map[string]struct{})
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen[FromContext(r.Context())] = struct{}{}
+	}))
+
+	for range 100 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/foo/1", nil))
+	}
+	assert.Len(t, seen, 100)
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "req-1", Resolve("req-1"))
+
+	for _, id := range []string{"", "req\n1", strings.Repeat("a", maxLen+1)} {
+		got := Resolve(id)
+		assert.NotEqual(t, id, got)
+		assert.Len(t, got, 32)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		log      func(ctx context.Context, logger *slog.Logger)
+		expected string
+	}{
+		{
+			name: "request ID at top level",
+			ctx:  NewContext(context.Background(), "42"),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.ErrorContext(ctx, "Failed.", slog.String("error", "boom"))
+			},
+			expected: `{"level": "ERROR", "msg": "Failed.", "request-id": "42", "error": "boom"}`,
+		},
+		{
+			name: "request ID outside of groups",
+			ctx:  NewContext(context.Background(), "42"),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.With(slog.String("app", "demo")).WithGroup("foo-rest-handler").
+					ErrorContext(ctx, "Failed.", slog.String("error", "boom"))
+			},
+			expected: `{
+				"level": "ERROR",
+				"msg": "Failed.",
+				"request-id": "42",
+				"app": "demo",
+				"foo-rest-handler": {"error": "boom"}
+			}`,
+		},
+		{
+			name: "no request ID in context",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.WithGroup("foo-rest-handler").ErrorContext(ctx, "Failed.", slog.String("error", "boom"))
+			},
+			expected: `{"level": "ERROR", "msg": "Failed.", "foo-rest-handler": {"error": "boom"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := slog.New(NewLogHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})))
+
+			tc.log(tc.ctx, logger)
+
+			assert.JSONEq(t, tc.expected, buf.String())
+		})
+	}
+}
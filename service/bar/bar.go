@@ -1,38 +1,179 @@
 package bar
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Bar is the bar entity handled by the domain layer.
+type Bar struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// BarPatch describes a partial update of a bar entity.
+// Nil fields are left untouched.
+type BarPatch struct {
+	Name *string
+}
+
 type repository interface {
-	Fetch() error
+	Fetch(ctx context.Context, id int64) (Bar, error)
+	List(ctx context.Context) ([]Bar, error)
+	Create(ctx context.Context, bar Bar) (Bar, error)
+	Update(ctx context.Context, bar Bar) (Bar, error)
+	Delete(ctx context.Context, id int64) error
 }
 
 // Service implements the domain layer for handling bar entities.
 type Service struct {
-	repo repository
+	repo   repository
+	tracer trace.Tracer
 }
 
+// Option applies custom behavior to the service.
+type Option func(s *Service)
+
+// WithTracerProvider is an option to trace the service with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Service) {
+		s.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// tracerName is the name of the tracer of the service.
+const tracerName = "github.com/alesr/resterrdemo/service/bar"
+
 // New instantiates a new service struct.
-func New(repo repository) *Service {
-	return &Service{repo: repo}
+func New(repo repository, opts ...Option) *Service {
+	s := Service{repo: repo, tracer: tracing.Tracer(nil, tracerName)}
+	for _, o := range opts {
+		o(&s)
+	}
+	return &s
 }
 
 // Fetch would naturally perform some business logic,
 // fetching the bar entity from the repository layer.
-func (s *Service) Fetch() error {
-	if err := s.repo.Fetch(); err != nil {
+func (s *Service) Fetch(ctx context.Context, id int64) (Bar, error) {
+	ctx, span := s.tracer.Start(ctx, "bar.Service.Fetch", trace.WithAttributes(attribute.Int64("bar.id", id)))
+	defer span.End()
+
+	bar, err := s.repo.Fetch(ctx, id)
+	if err != nil {
 		// In this example, we don't want to return this exact repository error to the transport layer.
-		// Instead, we replace it with something that better represents our use case (e.g., unavailability).
-		if errors.Is(err, ErrBarNotFound) {
-			return fmt.Errorf("could not fetch bar (%w): %w", err, ErrBarUnavailable)
-		}
+		// Instead, we replace it with something that better represents our use case, as for the other operations.
+		return Bar{}, tracing.RecordError(span, translateRepoErr(err, "fetch"))
+	}
+	return bar, nil
+}
+
+// List fetches all bar entities from the repository layer.
+func (s *Service) List(ctx context.Context) ([]Bar, error) {
+	ctx, span := s.tracer.Start(ctx, "bar.Service.List")
+	defer span.End()
+
+	bars, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, tracing.RecordError(span, translateRepoErr(err, "list"))
+	}
+	return bars, nil
+}
+
+// Create validates and stores a new bar entity.
+func (s *Service) Create(ctx context.Context, bar Bar) (Bar, error) {
+	ctx, span := s.tracer.Start(ctx, "bar.Service.Create")
+	defer span.End()
+
+	if err := bar.validate(); err != nil {
+		return Bar{}, tracing.RecordError(span, fmt.Errorf("could not create bar: %w", err))
+	}
+
+	created, err := s.repo.Create(ctx, bar)
+	if err != nil {
+		return Bar{}, tracing.RecordError(span, translateRepoErr(err, "create"))
+	}
+	return created, nil
+}
+
+// Update validates and replaces an existing bar entity.
+func (s *Service) Update(ctx context.Context, bar Bar) (Bar, error) {
+	ctx, span := s.tracer.Start(ctx, "bar.Service.Update", trace.WithAttributes(attribute.Int64("bar.id", bar.ID)))
+	defer span.End()
 
-		// If we encounter an unexpected error that we're not prepared to handle,
-		// we can add the context we need and safely return it,
-		// knowing that it won't be mapped as one of the known errors in the error map.
-		return fmt.Errorf("could not fetch bar from repo: %w", err)
+	if err := bar.validate(); err != nil {
+		return Bar{}, tracing.RecordError(span, fmt.Errorf("could not update bar: %w", err))
+	}
+
+	updated, err := s.repo.Update(ctx, bar)
+	if err != nil {
+		return Bar{}, tracing.RecordError(span, translateRepoErr(err, "update"))
+	}
+	return updated, nil
+}
+
+// Patch applies a partial update to an existing bar entity.
+func (s *Service) Patch(ctx context.Context, id int64, patch BarPatch) (Bar, error) {
+	ctx, span := s.tracer.Start(ctx, "bar.Service.Patch", trace.WithAttributes(attribute.Int64("bar.id", id)))
+	defer span.End()
+
+	bar, err := s.repo.Fetch(ctx, id)
+	if err != nil {
+		return Bar{}, tracing.RecordError(span, translateRepoErr(err, "patch"))
+	}
+
+	if patch.Name != nil {
+		bar.Name = *patch.Name
+	}
+
+	patched, err := s.Update(ctx, bar)
+	if err != nil {
+		return Bar{}, tracing.RecordError(span, err)
+	}
+	return patched, nil
+}
+
+// Delete removes an existing bar entity.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "bar.Service.Delete", trace.WithAttributes(attribute.Int64("bar.id", id)))
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return tracing.RecordError(span, translateRepoErr(err, "delete"))
+	}
+	return nil
+}
+
+func (b Bar) validate() error {
+	var violations []domainerr.FieldViolation
+
+	if strings.TrimSpace(b.Name) == "" {
+		violations = append(violations, domainerr.FieldViolation{Field: "name", Reason: "must not be empty"})
+	}
+
+	if len(violations) > 0 {
+		return &domainerr.ValidationError{Err: ErrInvalidBar, Violations: violations}
 	}
 	return nil
 }
+
+// translateRepoErr replaces the repository errors we know about with the service errors
+// that represent them for the operation. Unexpected errors are returned with added context.
+func translateRepoErr(err error, op string) error {
+	switch {
+	case errors.Is(err, ErrBarNotFound):
+		return fmt.Errorf("could not %s bar (%w): %w", op, err, ErrNoSuchBar)
+	case errors.Is(err, ErrBarDuplicate):
+		return fmt.Errorf("could not %s bar (%w): %w", op, err, ErrBarNameTaken)
+	case errors.Is(err, ErrBarStorageUnavailable):
+		return fmt.Errorf("could not %s bar (%w): %w", op, err, ErrBarUnavailable)
+	}
+	return fmt.Errorf("could not %s bar from repo: %w", op, err)
+}
@@ -1,18 +1,43 @@
 package bar
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type repoMock struct {
-	fetchFunc func() error
+	fetchFunc  func(ctx context.Context, id int64) (Bar, error)
+	listFunc   func(ctx context.Context) ([]Bar, error)
+	createFunc func(ctx context.Context, bar Bar) (Bar, error)
+	updateFunc func(ctx context.Context, bar Bar) (Bar, error)
+	deleteFunc func(ctx context.Context, id int64) error
 }
 
-func (m *repoMock) Fetch() error {
-	return m.fetchFunc()
+func (m *repoMock) Fetch(ctx context.Context, id int64) (Bar, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *repoMock) List(ctx context.Context) ([]Bar, error) {
+	return m.listFunc(ctx)
+}
+
+func (m *repoMock) Create(ctx context.Context, bar Bar) (Bar, error) {
+	return m.createFunc(ctx, bar)
+}
+
+func (m *repoMock) Update(ctx context.Context, bar Bar) (Bar, error) {
+	return m.updateFunc(ctx, bar)
+}
+
+func (m *repoMock) Delete(ctx context.Context, id int64) error {
+	return m.deleteFunc(ctx, id)
 }
 
 func TestNew(t *testing.T) {
@@ -31,13 +56,24 @@ func TestService_Fetch(t *testing.T) {
 
 	testCases := []struct {
 		name          string
+		repoBar       Bar
 		repoResult    error
+		expectedBar   Bar
 		expectedError error
-		errorContains string
 	}{
+		{
+			name:        "BarFound",
+			repoBar:     Bar{ID: 42, Name: "bar"},
+			expectedBar: Bar{ID: 42, Name: "bar"},
+		},
 		{
 			name:          "BarNotFound",
 			repoResult:    ErrBarNotFound,
+			expectedError: ErrNoSuchBar,
+		},
+		{
+			name:          "BarStorageUnavailable",
+			repoResult:    ErrBarStorageUnavailable,
 			expectedError: ErrBarUnavailable,
 		},
 		{
@@ -45,6 +81,11 @@ func TestService_Fetch(t *testing.T) {
 			repoResult:    assert.AnError,
 			expectedError: assert.AnError,
 		},
+		{
+			name:          "ContextCanceled",
+			repoResult:    context.Canceled,
+			expectedError: context.Canceled,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -54,18 +95,368 @@ func TestService_Fetch(t *testing.T) {
 			var fetchWasCalled bool
 
 			repo := repoMock{
-				fetchFunc: func() error {
+				fetchFunc: func(ctx context.Context, id int64) (Bar, error) {
 					fetchWasCalled = true
-					return tc.repoResult
+					assert.Equal(t, int64(42), id)
+					return tc.repoBar, tc.repoResult
 				},
 			}
 
-			svc := Service{repo: &repo}
+			exporter := tracetest.NewInMemoryExporter()
+			svc := New(&repo, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
 
-			got := svc.Fetch()
+			got, err := svc.Fetch(context.TODO(), 42)
 
 			require.True(t, fetchWasCalled)
-			assert.ErrorIs(t, got, tc.expectedError)
+			assert.Equal(t, tc.expectedBar, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "bar.Service.Fetch", spans[0].Name)
+			assert.Contains(t, spans[0].Attributes, attribute.Int64("bar.id", 42))
+
+			if tc.expectedError == nil {
+				assert.Equal(t, codes.Unset, spans[0].Status.Code)
+				assert.Empty(t, spans[0].Events)
+				return
+			}
+
+			assert.Equal(t, codes.Error, spans[0].Status.Code)
+			assert.Equal(t, err.Error(), spans[0].Status.Description)
+			require.Len(t, spans[0].Events, 1)
+			assert.Equal(t, "exception", spans[0].Events[0].Name)
+		})
+	}
+}
+
+func TestService_List(t *testing.T) {
+	t.Parallel()
+
+	t.Run("repository returns bars", func(t *testing.T) {
+		t.Parallel()
+
+		want := []Bar{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}
+
+		svc := New(&repoMock{
+			listFunc: func(ctx context.Context) ([]Bar, error) {
+				return want, nil
+			},
+		})
+
+		got, err := svc.List(context.TODO())
+		require.NoError(t, err)
+
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("repository returns error", func(t *testing.T) {
+		t.Parallel()
+
+		svc := New(&repoMock{
+			listFunc: func(ctx context.Context) ([]Bar, error) {
+				return nil, ErrBarStorageUnavailable
+			},
+		})
+
+		got, err := svc.List(context.TODO())
+
+		assert.ErrorIs(t, err, ErrBarUnavailable)
+		assert.Nil(t, got)
+	})
+}
+
+func TestService_Create(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		given         Bar
+		repoResult    error
+		expectedBar   Bar
+		expectedError error
+	}{
+		{
+			name:        "BarCreated",
+			given:       Bar{Name: "bar"},
+			expectedBar: Bar{ID: 42, Name: "bar"},
+		},
+		{
+			name:          "MissingName",
+			given:         Bar{Name: " "},
+			expectedError: ErrInvalidBar,
+		},
+		{
+			name:          "DuplicateName",
+			given:         Bar{Name: "bar"},
+			repoResult:    ErrBarDuplicate,
+			expectedError: ErrBarNameTaken,
+		},
+		{
+			name:          "UnexpectedError",
+			given:         Bar{Name: "bar"},
+			repoResult:    assert.AnError,
+			expectedError: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				createFunc: func(ctx context.Context, bar Bar) (Bar, error) {
+					if tc.repoResult != nil {
+						return Bar{}, tc.repoResult
+					}
+					bar.ID = 42
+					return bar, nil
+				},
+			})
+
+			got, err := svc.Create(context.TODO(), tc.given)
+
+			assert.Equal(t, tc.expectedBar, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		given         Bar
+		repoResult    error
+		expectedBar   Bar
+		expectedError error
+	}{
+		{
+			name:        "BarUpdated",
+			given:       Bar{ID: 42, Name: "bar"},
+			expectedBar: Bar{ID: 42, Name: "bar"},
+		},
+		{
+			name:          "MissingName",
+			given:         Bar{ID: 42},
+			expectedError: ErrInvalidBar,
+		},
+		{
+			name:          "BarNotFound",
+			given:         Bar{ID: 42, Name: "bar"},
+			repoResult:    ErrBarNotFound,
+			expectedError: ErrNoSuchBar,
+		},
+		{
+			name:          "BarStorageUnavailable",
+			given:         Bar{ID: 42, Name: "bar"},
+			repoResult:    ErrBarStorageUnavailable,
+			expectedError: ErrBarUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				updateFunc: func(ctx context.Context, bar Bar) (Bar, error) {
+					if tc.repoResult != nil {
+						return Bar{}, tc.repoResult
+					}
+					return bar, nil
+				},
+			})
+
+			got, err := svc.Update(context.TODO(), tc.given)
+
+			assert.Equal(t, tc.expectedBar, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Patch(t *testing.T) {
+	t.Parallel()
+
+	newName := "patched"
+	emptyName := ""
+
+	testCases := []struct {
+		name          string
+		given         BarPatch
+		fetchResult   error
+		expectedBar   Bar
+		expectedError error
+	}{
+		{
+			name:        "NamePatched",
+			given:       BarPatch{Name: &newName},
+			expectedBar: Bar{ID: 42, Name: "patched"},
+		},
+		{
+			name:        "NothingToPatch",
+			given:       BarPatch{},
+			expectedBar: Bar{ID: 42, Name: "bar"},
+		},
+		{
+			name:          "EmptyName",
+			given:         BarPatch{Name: &emptyName},
+			expectedError: ErrInvalidBar,
+		},
+		{
+			name:          "BarNotFound",
+			given:         BarPatch{Name: &newName},
+			fetchResult:   ErrBarNotFound,
+			expectedError: ErrNoSuchBar,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				fetchFunc: func(ctx context.Context, id int64) (Bar, error) {
+					if tc.fetchResult != nil {
+						return Bar{}, tc.fetchResult
+					}
+					return Bar{ID: id, Name: "bar"}, nil
+				},
+				updateFunc: func(ctx context.Context, bar Bar) (Bar, error) {
+					return bar, nil
+				},
+			})
+
+			got, err := svc.Patch(context.TODO(), 42, tc.given)
+
+			assert.Equal(t, tc.expectedBar, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		repoResult    error
+		expectedError error
+	}{
+		{
+			name: "BarDeleted",
+		},
+		{
+			name:          "BarNotFound",
+			repoResult:    ErrBarNotFound,
+			expectedError: ErrNoSuchBar,
+		},
+		{
+			name:          "UnexpectedError",
+			repoResult:    assert.AnError,
+			expectedError: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				deleteFunc: func(ctx context.Context, id int64) error {
+					assert.Equal(t, int64(42), id)
+					return tc.repoResult
+				},
+			})
+
+			err := svc.Delete(context.TODO(), 42)
+
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Tracing(t *testing.T) {
+	t.Parallel()
+
+	repo := repoMock{
+		fetchFunc:  func(_ context.Context, id int64) (Bar, error) { return Bar{ID: id, Name: "bar"}, nil },
+		listFunc:   func(context.Context) ([]Bar, error) { return nil, ErrBarStorageUnavailable },
+		createFunc: func(_ context.Context, bar Bar) (Bar, error) { return bar, nil },
+		updateFunc: func(_ context.Context, bar Bar) (Bar, error) { return bar, nil },
+		deleteFunc: func(context.Context, int64) error { return nil },
+	}
+
+	testCases := []struct {
+		name           string
+		call           func(svc *Service) error
+		expectedSpans  []string
+		expectedAttr   attribute.KeyValue
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "list",
+			call:           func(svc *Service) error { _, err := svc.List(context.TODO()); return err },
+			expectedSpans:  []string{"bar.Service.List"},
+			expectedStatus: codes.Error,
+		},
+		{
+			name:           "create",
+			call:           func(svc *Service) error { _, err := svc.Create(context.TODO(), Bar{Name: ""}); return err },
+			expectedSpans:  []string{"bar.Service.Create"},
+			expectedStatus: codes.Error,
+		},
+		{
+			name:          "update",
+			call:          func(svc *Service) error { _, err := svc.Update(context.TODO(), Bar{ID: 42, Name: "bar"}); return err },
+			expectedSpans: []string{"bar.Service.Update"},
+			expectedAttr:  attribute.Int64("bar.id", 42),
+		},
+		{
+			name: "patch",
+			call: func(svc *Service) error {
+				name := "patched"
+				_, err := svc.Patch(context.TODO(), 42, BarPatch{Name: &name})
+				return err
+			},
+			expectedSpans: []string{"bar.Service.Update", "bar.Service.Patch"},
+			expectedAttr:  attribute.Int64("bar.id", 42),
+		},
+		{
+			name:          "delete",
+			call:          func(svc *Service) error { return svc.Delete(context.TODO(), 42) },
+			expectedSpans: []string{"bar.Service.Delete"},
+			expectedAttr:  attribute.Int64("bar.id", 42),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			svc := New(&repo, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+
+			err := tc.call(svc)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, len(tc.expectedSpans))
+			for i, name := range tc.expectedSpans {
+				assert.Equal(t, name, spans[i].Name)
+			}
+
+			// The span of the operation ends last.
+			span := spans[len(spans)-1]
+			assert.Equal(t, tc.expectedStatus, span.Status.Code)
+			if tc.expectedStatus == codes.Error {
+				assert.Equal(t, err.Error(), span.Status.Description)
+			}
+
+			if tc.expectedAttr.Valid() {
+				assert.Contains(t, span.Attributes, tc.expectedAttr)
+			}
 		})
 	}
 }
@@ -1,6 +1,8 @@
 package bar
 
-import "errors"
+import (
+	"github.com/alesr/resterrdemo/service/domainerr"
+)
 
 var (
 	// Enumerate repository errors.
@@ -11,13 +13,32 @@ var (
 	// By defining the errors in the domain layer, we ensure that changes to the repository implementation
 	// won't require changes to the business logic. Instead, the new implementation will need to adapt
 	// to the domain layer, not the other way around.
-	ErrBarNotFound = errors.New("bar not found")
+	ErrBarNotFound           = domainerr.New(domainerr.KindNotFound, "bar.not_found", "bar not found")
+	ErrBarDuplicate          = domainerr.New(domainerr.KindConflict, "bar.duplicate", "bar already exists")
+	ErrBarStorageUnavailable = domainerr.NewRetryable(domainerr.KindUnavailable, "bar.storage_unavailable", "bar storage is unavailable")
 
 	// Enumerate service errors.
 	// These errors represent issues that can occur
 	// during the processing of business logic.
-	ErrBarUnavailable = errors.New("bar is unavailable at the moment")
-
-	// All errors in this list may or may not be included in the error map used by the error handler in the transport layer.
-	// If an error is not mapped to a JSON representation, it implies that the error should not be exposed to the client.
+	ErrBarUnavailable = domainerr.NewRetryable(domainerr.KindUnavailable, "bar.unavailable", "bar is unavailable at the moment")
+	ErrNoSuchBar      = domainerr.New(domainerr.KindNotFound, "bar.no_such_bar", "bar does not exist")
+	ErrBarNameTaken   = domainerr.New(domainerr.KindConflict, "bar.name_taken", "bar name is already taken")
+	ErrInvalidBar     = domainerr.New(domainerr.KindInvalid, "bar.invalid", "invalid bar")
 )
+
+// Errors declares the visibility of every error in this package.
+// Public errors must be mapped by the transport layer, while hidden errors must not:
+// they reach the client as unexpected errors, without details.
+var Errors = domainerr.Registry{
+	// Repository errors are translated by the service before reaching the transport layer.
+	{Err: ErrBarNotFound, Visibility: domainerr.Hidden},
+	{Err: ErrBarDuplicate, Visibility: domainerr.Hidden},
+	{Err: ErrBarStorageUnavailable, Visibility: domainerr.Hidden},
+
+	// In this case, we're choosing to not expose the bar unavailability,
+	// which results in the error being translated as a 500.
+	{Err: ErrBarUnavailable, Visibility: domainerr.Hidden},
+	{Err: ErrNoSuchBar, Visibility: domainerr.Public},
+	{Err: ErrBarNameTaken, Visibility: domainerr.Public},
+	{Err: ErrInvalidBar, Visibility: domainerr.Public},
+}
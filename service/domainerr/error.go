@@ -0,0 +1,88 @@
+package domainerr
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Kind classifies domain errors by the nature of the problem they describe,
+// so that transports can pick a sensible representation for errors they do not know about.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindNotFound
+	KindUnavailable
+	KindInvalid
+	KindConflict
+)
+
+// String implements the fmt.Stringer interface.
+func (k Kind) String() string {
+	switch k {
+	case KindUnknown:
+		return "unknown"
+	case KindNotFound:
+		return "not-found"
+	case KindUnavailable:
+		return "unavailable"
+	case KindInvalid:
+		return "invalid"
+	case KindConflict:
+		return "conflict"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Error is a domain error carrying, besides its identity, what the layers above
+// need to handle it: its kind, whether retrying may succeed and a message safe to show to clients.
+//
+// Errors are compared by code, so a copy carrying metadata still matches
+// the sentinel it was derived from with errors.Is.
+type Error struct {
+	// Code identifies the error. It is stable and meant to be read by machines.
+	Code string
+
+	// Kind classifies the error.
+	Kind Kind
+
+	// Retryable tells whether performing the same operation again may succeed.
+	Retryable bool
+
+	// Message describes the error. It must be safe to send to clients.
+	Message string
+
+	// Metadata holds details about an occurrence of the error.
+	Metadata map[string]string
+}
+
+// New returns a domain error that is not retryable.
+func New(kind Kind, code, message string) *Error {
+	return &Error{Code: code, Kind: kind, Message: message}
+}
+
+// NewRetryable returns a domain error that is retryable.
+func NewRetryable(kind Kind, code, message string) *Error {
+	return &Error{Code: code, Kind: kind, Retryable: true, Message: message}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return e.Message }
+
+// Is reports whether the target is a domain error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// With returns a copy of the error carrying the given metadata entry.
+func (e *Error) With(key, value string) *Error {
+	cp := *e
+	cp.Metadata = maps.Clone(e.Metadata)
+
+	if cp.Metadata == nil {
+		cp.Metadata = make(map[string]string, 1)
+	}
+	cp.Metadata[key] = value
+	return &cp
+}
@@ -0,0 +1,85 @@
+package domainerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	got := New(KindNotFound, "foo.not_found", "foo not found")
+
+	assert.Equal(t, &Error{Code: "foo.not_found", Kind: KindNotFound, Message: "foo not found"}, got)
+	assert.Equal(t, "foo not found", got.Error())
+}
+
+func TestNewRetryable(t *testing.T) {
+	t.Parallel()
+
+	got := NewRetryable(KindUnavailable, "foo.unavailable", "foo unavailable")
+
+	assert.Equal(t, &Error{Code: "foo.unavailable", Kind: KindUnavailable, Retryable: true, Message: "foo unavailable"}, got)
+}
+
+func TestError_Is(t *testing.T) {
+	t.Parallel()
+
+	errFoo := New(KindNotFound, "foo.not_found", "foo not found")
+	errBar := New(KindNotFound, "bar.not_found", "bar not found")
+
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", errFoo), errFoo)
+	assert.ErrorIs(t, errFoo.With("id", "42"), errFoo)
+	assert.NotErrorIs(t, errFoo, errBar)
+	assert.NotErrorIs(t, errFoo, errors.New("foo not found"))
+}
+
+func TestError_As(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("wrapped: %w", New(KindConflict, "foo.conflict", "foo conflict"))
+
+	var got *Error
+	require.ErrorAs(t, err, &got)
+
+	assert.Equal(t, "foo.conflict", got.Code)
+	assert.Equal(t, KindConflict, got.Kind)
+}
+
+func TestError_With(t *testing.T) {
+	t.Parallel()
+
+	errFoo := New(KindNotFound, "foo.not_found", "foo not found")
+
+	first := errFoo.With("id", "42")
+	second := first.With("name", "foo")
+
+	assert.Nil(t, errFoo.Metadata)
+	assert.Equal(t, map[string]string{"id": "42"}, first.Metadata)
+	assert.Equal(t, map[string]string{"id": "42", "name": "foo"}, second.Metadata)
+}
+
+func TestValidationError(t *testing.T) {
+	t.Parallel()
+
+	errInvalid := New(KindInvalid, "foo.invalid", "invalid foo")
+
+	err := fmt.Errorf("could not create foo: %w", &ValidationError{
+		Err: errInvalid,
+		Violations: []FieldViolation{
+			{Field: "name", Reason: "must not be empty"},
+			{Field: "size", Reason: "must be positive"},
+		},
+	})
+
+	assert.EqualError(t, err, "could not create foo: invalid foo: name: must not be empty, size: must be positive")
+	assert.ErrorIs(t, err, errInvalid)
+
+	var got *ValidationError
+	require.ErrorAs(t, err, &got)
+	assert.Len(t, got.Violations, 2)
+}
@@ -0,0 +1,66 @@
+// domainerr package holds the building blocks shared by the services
+// to declare their errors.
+package domainerr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Visibility tells whether an error may be exposed to the clients of the application.
+type Visibility int
+
+const (
+	// Public errors describe problems the client can act upon.
+	// Every transport must translate them into its own representation.
+	Public Visibility = iota + 1
+
+	// Hidden errors are deliberately kept from the clients.
+	// Transports must not translate them, so they reach the client as unexpected errors.
+	Hidden
+)
+
+// String implements the fmt.Stringer interface.
+func (v Visibility) String() string {
+	switch v {
+	case Public:
+		return "public"
+	case Hidden:
+		return "hidden"
+	}
+	return fmt.Sprintf("Visibility(%d)", int(v))
+}
+
+// Sentinel is an error declared by a service, together with its visibility.
+type Sentinel struct {
+	Err        error
+	Visibility Visibility
+}
+
+// Registry enumerates the errors a service may return.
+// Declaring the visibility of every error makes the difference between an error
+// we chose to hide and an error we forgot to map.
+type Registry []Sentinel
+
+// Validate checks the registry against a transport error mapping.
+// isMapped reports whether the transport translates the given error.
+// It returns every public error that is not mapped and every hidden error that is.
+func (r Registry) Validate(isMapped func(err error) bool) error {
+	var errs []error
+
+	for _, s := range r {
+		switch s.Visibility {
+		case Public:
+			if !isMapped(s.Err) {
+				errs = append(errs, fmt.Errorf("public error '%v' is not mapped", s.Err))
+			}
+		case Hidden:
+			if isMapped(s.Err) {
+				errs = append(errs, fmt.Errorf("hidden error '%v' is mapped", s.Err))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("error '%v' has an invalid visibility '%s'", s.Err, s.Visibility))
+		}
+	}
+	return errors.Join(errs...)
+}
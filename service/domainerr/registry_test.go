@@ -0,0 +1,84 @@
+package domainerr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Validate(t *testing.T) {
+	t.Parallel()
+
+	errPublic := errors.New("public")
+	errHidden := errors.New("hidden")
+
+	registry := Registry{
+		{Err: errPublic, Visibility: Public},
+		{Err: errHidden, Visibility: Hidden},
+	}
+
+	testCases := []struct {
+		name           string
+		registry       Registry
+		mapped         []error
+		expectedErrors []string
+	}{
+		{
+			name:     "public errors mapped and hidden errors not mapped",
+			registry: registry,
+			mapped:   []error{errPublic},
+		},
+		{
+			name:           "public error not mapped",
+			registry:       registry,
+			expectedErrors: []string{"public error 'public' is not mapped"},
+		},
+		{
+			name:     "hidden error mapped",
+			registry: registry,
+			mapped:   []error{errPublic, errHidden},
+			expectedErrors: []string{
+				"hidden error 'hidden' is mapped",
+			},
+		},
+		{
+			name:     "every problem is reported",
+			registry: append(registry, Sentinel{Err: errors.New("undeclared")}),
+			mapped:   []error{errHidden},
+			expectedErrors: []string{
+				"public error 'public' is not mapped",
+				"hidden error 'hidden' is mapped",
+				"error 'undeclared' has an invalid visibility 'Visibility(0)'",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			isMapped := func(err error) bool {
+				for _, m := range tc.mapped {
+					if errors.Is(err, m) {
+						return true
+					}
+				}
+				return false
+			}
+
+			err := tc.registry.Validate(isMapped)
+
+			if len(tc.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			for _, expected := range tc.expectedErrors {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
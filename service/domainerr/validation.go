@@ -0,0 +1,32 @@
+package domainerr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldViolation describes why a field of an entity is invalid.
+type FieldViolation struct {
+	Field  string
+	Reason string
+}
+
+// ValidationError is returned when an entity is invalid.
+// It wraps the error declared by the service for invalid entities,
+// and lists every invalid field so that they can all be reported at once.
+type ValidationError struct {
+	Err        error
+	Violations []FieldViolation
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, fmt.Sprintf("%s: %s", v.Field, v.Reason))
+	}
+	return fmt.Sprintf("%s: %s", e.Err, strings.Join(violations, ", "))
+}
+
+// Unwrap returns the wrapped service error.
+func (e *ValidationError) Unwrap() error { return e.Err }
@@ -1,15 +1,39 @@
 package foo
 
 import (
-	"errors"
+	"github.com/alesr/resterrdemo/service/domainerr"
 )
 
 var (
+	// Enumerate repository errors.
+	// These errors are used by the repository layer to represent failed
+	// operations when interacting with the database.
+	// We define them here, not in the repository package, to avoid creating
+	// a dependency between the domain layer and the storage layer.
+	ErrFooNotFound           = domainerr.New(domainerr.KindNotFound, "foo.not_found", "foo not found")
+	ErrFooStorageUnavailable = domainerr.NewRetryable(domainerr.KindUnavailable, "foo.storage_unavailable", "foo storage is unavailable")
+
 	// Enumerate service errors.
 	// These errors represent issues that can occur
 	// during the processing of business logic.
-	ErrGetFaleid = errors.New("could not get foo")
-
-	// All errors in this list may or may not be included in the error map used by the error handler in the transport layer.
-	// If an error is not mapped to a JSON representation, it implies that the error should not be exposed to the client.
+	ErrGetFaleid    = domainerr.NewRetryable(domainerr.KindUnavailable, "foo.get_failed", "could not get foo")
+	ErrListFailed   = domainerr.NewRetryable(domainerr.KindUnavailable, "foo.list_failed", "could not list foo")
+	ErrCreateFailed = domainerr.NewRetryable(domainerr.KindUnavailable, "foo.create_failed", "could not create foo")
+	ErrUpdateFailed = domainerr.NewRetryable(domainerr.KindUnavailable, "foo.update_failed", "could not update foo")
+	ErrDeleteFailed = domainerr.NewRetryable(domainerr.KindUnavailable, "foo.delete_failed", "could not delete foo")
+	ErrInvalidFoo   = domainerr.New(domainerr.KindInvalid, "foo.invalid", "invalid foo")
 )
+
+// Errors declares the visibility of every error in this package.
+// Public errors must be mapped by the transport layer, while hidden errors must not:
+// they reach the client as unexpected errors, without details.
+var Errors = domainerr.Registry{
+	{Err: ErrFooNotFound, Visibility: domainerr.Public},
+	{Err: ErrFooStorageUnavailable, Visibility: domainerr.Hidden},
+	{Err: ErrGetFaleid, Visibility: domainerr.Public},
+	{Err: ErrListFailed, Visibility: domainerr.Public},
+	{Err: ErrCreateFailed, Visibility: domainerr.Public},
+	{Err: ErrUpdateFailed, Visibility: domainerr.Public},
+	{Err: ErrDeleteFailed, Visibility: domainerr.Public},
+	{Err: ErrInvalidFoo, Visibility: domainerr.Public},
+}
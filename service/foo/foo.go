@@ -1,22 +1,173 @@
 package foo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/alesr/resterrdemo/service/domainerr"
+	"github.com/alesr/resterrdemo/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type repository interface{ Fetch() error }
+// Foo is the foo entity handled by the domain layer.
+type Foo struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// FooPatch describes a partial update of a foo entity.
+// Nil fields are left untouched.
+type FooPatch struct {
+	Name *string
+}
+
+type repository interface {
+	Fetch(ctx context.Context, id int64) (Foo, error)
+	List(ctx context.Context) ([]Foo, error)
+	Create(ctx context.Context, foo Foo) (Foo, error)
+	Update(ctx context.Context, foo Foo) (Foo, error)
+	Delete(ctx context.Context, id int64) error
+}
 
 // Service implements the domain layer for handling foo entities.
-type Service struct{ repo repository }
+type Service struct {
+	repo   repository
+	tracer trace.Tracer
+}
+
+// Option applies custom behavior to the service.
+type Option func(s *Service)
+
+// WithTracerProvider is an option to trace the service with the provider instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Service) {
+		s.tracer = tracing.Tracer(tp, tracerName)
+	}
+}
+
+// tracerName is the name of the tracer of the service.
+const tracerName = "github.com/alesr/resterrdemo/service/foo"
 
 // New instantiates a new service struct.
-func New(repo repository) *Service { return &Service{repo: repo} }
+func New(repo repository, opts ...Option) *Service {
+	s := Service{repo: repo, tracer: tracing.Tracer(nil, tracerName)}
+	for _, o := range opts {
+		o(&s)
+	}
+	return &s
+}
 
 // Fetch would naturally perform some business logic,
 // fetching the foo entity from the repository layer.
-func (s *Service) Fetch() error {
-	if err := s.repo.Fetch(); err != nil {
-		return fmt.Errorf("could not fetch foo from repo: '%s': %w", err, ErrGetFaleid)
+func (s *Service) Fetch(ctx context.Context, id int64) (Foo, error) {
+	ctx, span := s.tracer.Start(ctx, "foo.Service.Fetch", trace.WithAttributes(attribute.Int64("foo.id", id)))
+	defer span.End()
+
+	foo, err := s.repo.Fetch(ctx, id)
+	if err != nil {
+		return Foo{}, tracing.RecordError(span, wrapRepoErr(err, "fetch", ErrGetFaleid))
+	}
+	return foo, nil
+}
+
+// List fetches all foo entities from the repository layer.
+func (s *Service) List(ctx context.Context) ([]Foo, error) {
+	ctx, span := s.tracer.Start(ctx, "foo.Service.List")
+	defer span.End()
+
+	foos, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, tracing.RecordError(span, wrapRepoErr(err, "list", ErrListFailed))
+	}
+	return foos, nil
+}
+
+// Create validates and stores a new foo entity.
+func (s *Service) Create(ctx context.Context, foo Foo) (Foo, error) {
+	ctx, span := s.tracer.Start(ctx, "foo.Service.Create")
+	defer span.End()
+
+	if err := foo.validate(); err != nil {
+		return Foo{}, tracing.RecordError(span, fmt.Errorf("could not create foo: %w", err))
+	}
+
+	created, err := s.repo.Create(ctx, foo)
+	if err != nil {
+		return Foo{}, tracing.RecordError(span, wrapRepoErr(err, "create", ErrCreateFailed))
+	}
+	return created, nil
+}
+
+// Update validates and replaces an existing foo entity.
+func (s *Service) Update(ctx context.Context, foo Foo) (Foo, error) {
+	ctx, span := s.tracer.Start(ctx, "foo.Service.Update", trace.WithAttributes(attribute.Int64("foo.id", foo.ID)))
+	defer span.End()
+
+	if err := foo.validate(); err != nil {
+		return Foo{}, tracing.RecordError(span, fmt.Errorf("could not update foo: %w", err))
+	}
+
+	updated, err := s.repo.Update(ctx, foo)
+	if err != nil {
+		return Foo{}, tracing.RecordError(span, wrapRepoErr(err, "update", ErrUpdateFailed))
+	}
+	return updated, nil
+}
+
+// Patch applies a partial update to an existing foo entity.
+func (s *Service) Patch(ctx context.Context, id int64, patch FooPatch) (Foo, error) {
+	ctx, span := s.tracer.Start(ctx, "foo.Service.Patch", trace.WithAttributes(attribute.Int64("foo.id", id)))
+	defer span.End()
+
+	foo, err := s.repo.Fetch(ctx, id)
+	if err != nil {
+		return Foo{}, tracing.RecordError(span, wrapRepoErr(err, "patch", ErrUpdateFailed))
+	}
+
+	if patch.Name != nil {
+		foo.Name = *patch.Name
+	}
+
+	patched, err := s.Update(ctx, foo)
+	if err != nil {
+		return Foo{}, tracing.RecordError(span, err)
+	}
+	return patched, nil
+}
+
+// Delete removes an existing foo entity.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "foo.Service.Delete", trace.WithAttributes(attribute.Int64("foo.id", id)))
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return tracing.RecordError(span, wrapRepoErr(err, "delete", ErrDeleteFailed))
 	}
 	return nil
 }
+
+func (f Foo) validate() error {
+	var violations []domainerr.FieldViolation
+
+	if strings.TrimSpace(f.Name) == "" {
+		violations = append(violations, domainerr.FieldViolation{Field: "name", Reason: "must not be empty"})
+	}
+
+	if len(violations) > 0 {
+		return &domainerr.ValidationError{Err: ErrInvalidFoo, Violations: violations}
+	}
+	return nil
+}
+
+// wrapRepoErr lets not found errors reach the caller, since a missing foo is something
+// the client can act on, as well as context errors, which tell that the caller gave up on the operation.
+// Any other repository error is hidden behind the operation's error.
+func wrapRepoErr(err error, op string, opErr error) error {
+	if errors.Is(err, ErrFooNotFound) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("could not %s foo: %w", op, err)
+	}
+	return fmt.Errorf("could not %s foo from repo: '%s': %w", op, err, opErr)
+}
@@ -1,18 +1,43 @@
 package foo
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type repoMock struct {
-	fetchFunc func() error
+	fetchFunc  func(ctx context.Context, id int64) (Foo, error)
+	listFunc   func(ctx context.Context) ([]Foo, error)
+	createFunc func(ctx context.Context, foo Foo) (Foo, error)
+	updateFunc func(ctx context.Context, foo Foo) (Foo, error)
+	deleteFunc func(ctx context.Context, id int64) error
 }
 
-func (m *repoMock) Fetch() error {
-	return m.fetchFunc()
+func (m *repoMock) Fetch(ctx context.Context, id int64) (Foo, error) {
+	return m.fetchFunc(ctx, id)
+}
+
+func (m *repoMock) List(ctx context.Context) ([]Foo, error) {
+	return m.listFunc(ctx)
+}
+
+func (m *repoMock) Create(ctx context.Context, foo Foo) (Foo, error) {
+	return m.createFunc(ctx, foo)
+}
+
+func (m *repoMock) Update(ctx context.Context, foo Foo) (Foo, error) {
+	return m.updateFunc(ctx, foo)
+}
+
+func (m *repoMock) Delete(ctx context.Context, id int64) error {
+	return m.deleteFunc(ctx, id)
 }
 
 func TestNew(t *testing.T) {
@@ -29,19 +54,414 @@ func TestNew(t *testing.T) {
 func TestService_Fetch(t *testing.T) {
 	t.Parallel()
 
-	var fetchWasCalled bool
+	testCases := []struct {
+		name          string
+		repoFoo       Foo
+		repoResult    error
+		expectedFoo   Foo
+		expectedError error
+	}{
+		{
+			name:        "FooFound",
+			repoFoo:     Foo{ID: 42, Name: "foo"},
+			expectedFoo: Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name:          "FooNotFound",
+			repoResult:    ErrFooNotFound,
+			expectedError: ErrFooNotFound,
+		},
+		{
+			name:          "FooStorageUnavailable",
+			repoResult:    ErrFooStorageUnavailable,
+			expectedError: ErrGetFaleid,
+		},
+		{
+			name:          "UnexpectedError",
+			repoResult:    assert.AnError,
+			expectedError: ErrGetFaleid,
+		},
+		{
+			name:          "ContextCanceled",
+			repoResult:    context.Canceled,
+			expectedError: context.Canceled,
+		},
+		{
+			name:          "ContextDeadlineExceeded",
+			repoResult:    context.DeadlineExceeded,
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var fetchWasCalled bool
+
+			repo := repoMock{
+				fetchFunc: func(ctx context.Context, id int64) (Foo, error) {
+					fetchWasCalled = true
+					assert.Equal(t, int64(42), id)
+					return tc.repoFoo, tc.repoResult
+				},
+			}
+
+			exporter := tracetest.NewInMemoryExporter()
+			svc := New(&repo, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+
+			got, err := svc.Fetch(context.TODO(), 42)
+
+			require.True(t, fetchWasCalled)
+			assert.Equal(t, tc.expectedFoo, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "foo.Service.Fetch", spans[0].Name)
+			assert.Contains(t, spans[0].Attributes, attribute.Int64("foo.id", 42))
+
+			if tc.expectedError == nil {
+				assert.Equal(t, codes.Unset, spans[0].Status.Code)
+				assert.Empty(t, spans[0].Events)
+				return
+			}
+
+			assert.Equal(t, codes.Error, spans[0].Status.Code)
+			assert.Equal(t, err.Error(), spans[0].Status.Description)
+			require.Len(t, spans[0].Events, 1)
+			assert.Equal(t, "exception", spans[0].Events[0].Name)
+
+			// Repository details must not be matchable once hidden behind the service error.
+			if tc.expectedError == ErrGetFaleid {
+				assert.NotErrorIs(t, err, tc.repoResult)
+			}
+		})
+	}
+}
+
+func TestService_List(t *testing.T) {
+	t.Parallel()
+
+	t.Run("repository returns foos", func(t *testing.T) {
+		t.Parallel()
+
+		want := []Foo{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}
+
+		svc := New(&repoMock{
+			listFunc: func(ctx context.Context) ([]Foo, error) {
+				return want, nil
+			},
+		})
+
+		got, err := svc.List(context.TODO())
+		require.NoError(t, err)
+
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("repository returns error", func(t *testing.T) {
+		t.Parallel()
+
+		svc := New(&repoMock{
+			listFunc: func(ctx context.Context) ([]Foo, error) {
+				return nil, ErrFooStorageUnavailable
+			},
+		})
+
+		got, err := svc.List(context.TODO())
+
+		assert.ErrorIs(t, err, ErrListFailed)
+		assert.NotErrorIs(t, err, ErrFooStorageUnavailable)
+		assert.Nil(t, got)
+	})
+}
+
+func TestService_Create(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		given         Foo
+		repoResult    error
+		expectedFoo   Foo
+		expectedError error
+	}{
+		{
+			name:        "FooCreated",
+			given:       Foo{Name: "foo"},
+			expectedFoo: Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name:          "MissingName",
+			given:         Foo{Name: " "},
+			expectedError: ErrInvalidFoo,
+		},
+		{
+			name:          "RepositoryError",
+			given:         Foo{Name: "foo"},
+			repoResult:    assert.AnError,
+			expectedError: ErrCreateFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				createFunc: func(ctx context.Context, foo Foo) (Foo, error) {
+					if tc.repoResult != nil {
+						return Foo{}, tc.repoResult
+					}
+					foo.ID = 42
+					return foo, nil
+				},
+			})
+
+			got, err := svc.Create(context.TODO(), tc.given)
+
+			assert.Equal(t, tc.expectedFoo, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		given         Foo
+		repoResult    error
+		expectedFoo   Foo
+		expectedError error
+	}{
+		{
+			name:        "FooUpdated",
+			given:       Foo{ID: 42, Name: "foo"},
+			expectedFoo: Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name:          "MissingName",
+			given:         Foo{ID: 42},
+			expectedError: ErrInvalidFoo,
+		},
+		{
+			name:          "FooNotFound",
+			given:         Foo{ID: 42, Name: "foo"},
+			repoResult:    ErrFooNotFound,
+			expectedError: ErrFooNotFound,
+		},
+		{
+			name:          "RepositoryError",
+			given:         Foo{ID: 42, Name: "foo"},
+			repoResult:    assert.AnError,
+			expectedError: ErrUpdateFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				updateFunc: func(ctx context.Context, foo Foo) (Foo, error) {
+					if tc.repoResult != nil {
+						return Foo{}, tc.repoResult
+					}
+					return foo, nil
+				},
+			})
+
+			got, err := svc.Update(context.TODO(), tc.given)
+
+			assert.Equal(t, tc.expectedFoo, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Patch(t *testing.T) {
+	t.Parallel()
+
+	newName := "patched"
+	emptyName := ""
+
+	testCases := []struct {
+		name          string
+		given         FooPatch
+		fetchResult   error
+		expectedFoo   Foo
+		expectedError error
+	}{
+		{
+			name:        "NamePatched",
+			given:       FooPatch{Name: &newName},
+			expectedFoo: Foo{ID: 42, Name: "patched"},
+		},
+		{
+			name:        "NothingToPatch",
+			given:       FooPatch{},
+			expectedFoo: Foo{ID: 42, Name: "foo"},
+		},
+		{
+			name:          "EmptyName",
+			given:         FooPatch{Name: &emptyName},
+			expectedError: ErrInvalidFoo,
+		},
+		{
+			name:          "FooNotFound",
+			given:         FooPatch{Name: &newName},
+			fetchResult:   ErrFooNotFound,
+			expectedError: ErrFooNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				fetchFunc: func(ctx context.Context, id int64) (Foo, error) {
+					if tc.fetchResult != nil {
+						return Foo{}, tc.fetchResult
+					}
+					return Foo{ID: id, Name: "foo"}, nil
+				},
+				updateFunc: func(ctx context.Context, foo Foo) (Foo, error) {
+					return foo, nil
+				},
+			})
+
+			got, err := svc.Patch(context.TODO(), 42, tc.given)
+
+			assert.Equal(t, tc.expectedFoo, got)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		repoResult    error
+		expectedError error
+	}{
+		{
+			name: "FooDeleted",
+		},
+		{
+			name:          "FooNotFound",
+			repoResult:    ErrFooNotFound,
+			expectedError: ErrFooNotFound,
+		},
+		{
+			name:          "RepositoryError",
+			repoResult:    assert.AnError,
+			expectedError: ErrDeleteFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := New(&repoMock{
+				deleteFunc: func(ctx context.Context, id int64) error {
+					assert.Equal(t, int64(42), id)
+					return tc.repoResult
+				},
+			})
+
+			err := svc.Delete(context.TODO(), 42)
+
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestService_Tracing(t *testing.T) {
+	t.Parallel()
 
 	repo := repoMock{
-		fetchFunc: func() error {
-			fetchWasCalled = true
-			return assert.AnError
+		fetchFunc:  func(_ context.Context, id int64) (Foo, error) { return Foo{ID: id, Name: "foo"}, nil },
+		listFunc:   func(context.Context) ([]Foo, error) { return nil, ErrFooStorageUnavailable },
+		createFunc: func(_ context.Context, foo Foo) (Foo, error) { return foo, nil },
+		updateFunc: func(_ context.Context, foo Foo) (Foo, error) { return foo, nil },
+		deleteFunc: func(context.Context, int64) error { return nil },
+	}
+
+	testCases := []struct {
+		name           string
+		call           func(svc *Service) error
+		expectedSpans  []string
+		expectedAttr   attribute.KeyValue
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "list",
+			call:           func(svc *Service) error { _, err := svc.List(context.TODO()); return err },
+			expectedSpans:  []string{"foo.Service.List"},
+			expectedStatus: codes.Error,
+		},
+		{
+			name:           "create",
+			call:           func(svc *Service) error { _, err := svc.Create(context.TODO(), Foo{Name: ""}); return err },
+			expectedSpans:  []string{"foo.Service.Create"},
+			expectedStatus: codes.Error,
+		},
+		{
+			name:          "update",
+			call:          func(svc *Service) error { _, err := svc.Update(context.TODO(), Foo{ID: 42, Name: "foo"}); return err },
+			expectedSpans: []string{"foo.Service.Update"},
+			expectedAttr:  attribute.Int64("foo.id", 42),
+		},
+		{
+			name: "patch",
+			call: func(svc *Service) error {
+				name := "patched"
+				_, err := svc.Patch(context.TODO(), 42, FooPatch{Name: &name})
+				return err
+			},
+			expectedSpans: []string{"foo.Service.Update", "foo.Service.Patch"},
+			expectedAttr:  attribute.Int64("foo.id", 42),
+		},
+		{
+			name:          "delete",
+			call:          func(svc *Service) error { return svc.Delete(context.TODO(), 42) },
+			expectedSpans: []string{"foo.Service.Delete"},
+			expectedAttr:  attribute.Int64("foo.id", 42),
 		},
 	}
 
-	svc := Service{repo: &repo}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			svc := New(&repo, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
 
-	got := svc.Fetch()
+			err := tc.call(svc)
 
-	require.True(t, fetchWasCalled)
-	assert.ErrorIs(t, got, ErrGetFaleid)
+			spans := exporter.GetSpans()
+			require.Len(t, spans, len(tc.expectedSpans))
+			for i, name := range tc.expectedSpans {
+				assert.Equal(t, name, spans[i].Name)
+			}
+
+			// The span of the operation ends last.
+			span := spans[len(spans)-1]
+			assert.Equal(t, tc.expectedStatus, span.Status.Code)
+			if tc.expectedStatus == codes.Error {
+				assert.Equal(t, err.Error(), span.Status.Description)
+			}
+
+			if tc.expectedAttr.Valid() {
+				assert.Contains(t, span.Attributes, tc.expectedAttr)
+			}
+		})
+	}
 }
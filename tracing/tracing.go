@@ -0,0 +1,30 @@
+// tracing package holds the pieces shared by the instrumented layers of the application,
+// such as getting their tracer and recording errors on spans.
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer returns the tracer of the instrumented package from the provider,
+// or from the global provider when the provider is nil.
+func Tracer(tp trace.TracerProvider, name string) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(name)
+}
+
+// RecordError records the error on the span and marks the span as failed.
+// It returns the error, so it can be recorded where it is returned.
+func RecordError(span trace.Span, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecordError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		err                error
+		expectedStatusCode codes.Code
+		expectedEvents     int
+	}{
+		{
+			name:               "error",
+			err:                assert.AnError,
+			expectedStatusCode: codes.Error,
+			expectedEvents:     1,
+		},
+		{
+			name:               "no error",
+			expectedStatusCode: codes.Unset,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			_, span := Tracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), "test").Start(context.TODO(), "op")
+
+			err := RecordError(span, tc.err)
+			span.End()
+
+			assert.Equal(t, tc.err, err)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.expectedStatusCode, spans[0].Status.Code)
+			assert.Len(t, spans[0].Events, tc.expectedEvents)
+		})
+	}
+}